@@ -3,23 +3,41 @@ package main
 import (
 	"bufio"
 	"bytes"
-	"encoding/binary"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fabian-z/fabrico-ledger/gcodesim"
 	"github.com/hashicorp/go-uuid"
 )
 
+// Maximum time to wait for the ledger to commit a fabrication reservation
+const fabricationCommitTimeout = 30 * time.Second
+
+// Delays between attempts to close a fabrication reservation, doubling up to the maximum
+const (
+	cancelBackoffBase = time.Second
+	cancelBackoffMax  = 5 * time.Minute
+)
+
+// Maximum time clients may wait for submitted requests to be committed
+const maxSubmissionWait = time.Minute
+
 type APIServer struct {
 	Node                *Node
 	FabricationEndpoint string
+	Events              *eventBroker
+	TLSConfig           *tls.Config
+	Tokens              map[string]*Principal // keyed by hex encoded SHA-256 hash of the token
 }
 
 type NodeStatus struct {
@@ -31,34 +49,79 @@ type NodeStatus struct {
 	LastUpdateTime string
 	Records        int
 	TotalFiles     int
+	LatestSequence uint64
+	StateRoot      string // hex string of SHA3-256 state root, equal on nodes with equal LatestSequence
+}
+
+type SubmissionData struct {
+	FileHash string
+	Requests []*TxStatus
+}
+
+type GrantData struct {
+	ID        string
+	From      NodeID
+	To        NodeID
+	Count     int
+	Transfer  bool
+	Timestamp string // RFC 3339 in ledger time
 }
 
 type AvailableData struct {
 	OriginatingID NodeID
 	FileHash      string // hex string representing FabricationDataHash value
 	Remaining     int
+	Pending       int // announced, not yet produced or cancelled
+	Consumed      int
+	Revoked       bool
+	ValidFrom     string // RFC 3339 in ledger time, empty if unbounded
+	ValidUntil    string
 }
 
+// ServeHTTP serves the API over HTTPS. Requests are authenticated by client certificate or token,
+// each endpoint is restricted to the roles using it.
 func (a *APIServer) ServeHTTP(endpoint string) error {
-	http.HandleFunc("/api/status", a.NodeStatus)
-	http.HandleFunc("/api/availabledata", a.AvailableData)
-
-	http.HandleFunc("/api/addfile", a.AddFile)
-	http.HandleFunc("/api/fabricate", a.Fabricate)
+	http.HandleFunc("/api/login", a.Login)
+	http.HandleFunc("/api/logout", a.Logout)
+
+	http.HandleFunc("/api/status", a.authorize(a.NodeStatus, allRoles...))
+	http.HandleFunc("/api/availabledata", a.authorize(a.AvailableData, RoleManufacturer))
+	http.HandleFunc("/api/receipts", a.authorize(a.Receipts, RoleDataProvider))
+
+	http.HandleFunc("/api/blocks", a.authorize(a.Blocks, allRoles...))
+	http.HandleFunc("/api/block", a.authorize(a.Block, allRoles...))
+	http.HandleFunc("/api/tx", a.authorize(a.Transaction, allRoles...))
+	http.HandleFunc("/api/tx/", a.authorize(a.TransactionStatus, allRoles...))
+	http.HandleFunc("/api/history", a.authorize(a.History, allRoles...))
+	http.HandleFunc("/api/events", a.authorize(a.EventStream, allRoles...))
+
+	http.HandleFunc("/api/addfile", a.authorize(a.AddFile, RoleDataProvider))
+	http.HandleFunc("/api/revoke", a.authorize(a.RevokeFabrication, RoleDataProvider))
+	http.HandleFunc("/api/adjust", a.authorize(a.AdjustFabrication, RoleDataProvider))
+	http.HandleFunc("/api/transfer", a.authorize(a.TransferFabrication, RoleManufacturer))
+	http.HandleFunc("/api/provenance", a.authorize(a.Provenance, RoleDataProvider, RoleManufacturer))
+	http.HandleFunc("/api/fabricate", a.authorize(a.Fabricate, RoleManufacturer))
 
 	http.Handle("/", http.FileServer(http.Dir("ui/dist/")))
 
-	return http.ListenAndServe(endpoint, nil)
+	server := &http.Server{
+		Addr:      endpoint,
+		TLSConfig: a.TLSConfig,
+	}
+	return server.ListenAndServeTLS("", "")
 }
 
 func (a *APIServer) NodeStatus(w http.ResponseWriter, _ *http.Request) {
 
-	a.Node.cb.aggregationLock.Lock()
-	knownFiles := len(a.Node.cb.knownFiles)
-	a.Node.cb.aggregationLock.Unlock()
+	a.Node.cb.state.RLock()
+	knownFiles := len(a.Node.cb.state.knownFiles)
+	a.Node.cb.state.RUnlock()
 
+	// Read sequence and state root consistently, batches are applied under the records lock
 	a.Node.cb.lock.RLock()
 	records := len(a.Node.cb.records)
+	latestSequence := a.Node.cb.latestMD.GetLatestSequence()
+	stateRoot := a.Node.cb.state.Root()
 	a.Node.cb.lock.RUnlock()
 
 	status := &NodeStatus{
@@ -69,6 +132,8 @@ func (a *APIServer) NodeStatus(w http.ResponseWriter, _ *http.Request) {
 		LastUpdateTime: time.Now().Format(time.RFC1123),
 		Records:        records,
 		TotalFiles:     knownFiles,
+		LatestSequence: latestSequence,
+		StateRoot:      fmt.Sprintf("%x", stateRoot),
 	}
 
 	encoder := json.NewEncoder(w)
@@ -82,19 +147,17 @@ func (a *APIServer) NodeStatus(w http.ResponseWriter, _ *http.Request) {
 func (a *APIServer) AvailableData(w http.ResponseWriter, _ *http.Request) {
 	var available []*AvailableData
 
-	a.Node.cb.aggregationLock.Lock()
-	defer a.Node.cb.aggregationLock.Unlock()
-
-	for hash, allowed := range a.Node.cb.allowedNodes {
-		for _, allow := range allowed {
-			if a.Node.id == allow.NodeID && allow.RemainingCount > 0 {
-				available = append(available, &AvailableData{
-					OriginatingID: a.Node.cb.knownFiles[hash],
-					FileHash:      fmt.Sprintf("%x", hash),
-					Remaining:     allow.RemainingCount,
-				})
-			}
-		}
+	for _, allow := range a.Node.cb.state.available(a.Node.id, estimatedLedgerTime(a.Node.cb.state.Time())) {
+		available = append(available, &AvailableData{
+			OriginatingID: allow.Owner,
+			FileHash:      fmt.Sprintf("%x", allow.FileHash),
+			Remaining:     allow.RemainingCount,
+			Pending:       allow.ReservedCount,
+			Consumed:      allow.ConsumedCount,
+			Revoked:       allow.Revoked,
+			ValidFrom:     formatLedgerTime(allow.ValidFrom),
+			ValidUntil:    formatLedgerTime(allow.ValidUntil),
+		})
 	}
 
 	encoder := json.NewEncoder(w)
@@ -105,32 +168,250 @@ func (a *APIServer) AvailableData(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func (a *APIServer) AddFile(w http.ResponseWriter, req *http.Request) {
+type ReceiptData struct {
+	FileHash         string // hex string representing FabricationDataHash value
+	AnnounceID       string
+	FabricatingID    NodeID
+	Success          bool
+	CommandsSent     int64
+	Simulated        bool
+	Layers           int64
+	ExtrudedFilament float64 // mm
+	BoundingBox      [2][3]float64
+	Digest           string
+}
+
+// Receipts lists fabrication receipts of all files originating at this node
+func (a *APIServer) Receipts(w http.ResponseWriter, _ *http.Request) {
+	var receipts []*ReceiptData
+
+	for _, reservation := range a.Node.cb.state.receipts(a.Node.id) {
+		receipt := reservation.Receipt
+		sim := receipt.Simulation
+		receipts = append(receipts, &ReceiptData{
+			FileHash:         fmt.Sprintf("%x", receipt.FileHash),
+			AnnounceID:       receipt.AnnounceID,
+			FabricatingID:    reservation.NodeID,
+			Success:          receipt.Success,
+			CommandsSent:     receipt.CommandsSent,
+			Simulated:        sim.Simulated,
+			Layers:           sim.Layers,
+			ExtrudedFilament: float64(sim.ExtrudedFilament) / 1000,
+			BoundingBox: [2][3]float64{
+				{float64(sim.MinX) / 1000, float64(sim.MinY) / 1000, float64(sim.MinZ) / 1000},
+				{float64(sim.MaxX) / 1000, float64(sim.MaxY) / 1000, float64(sim.MaxZ) / 1000},
+			},
+			Digest: fmt.Sprintf("%x", receipt.Digest),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(receipts)
+
+	if err != nil {
+		a.Node.app.logger.Error(err)
+	}
+}
+
+// Provenance lists the grants through which a node received its allowance for a file,
+// given by the query parameters hash and node
+func (a *APIServer) Provenance(w http.ResponseWriter, req *http.Request) {
+	hash, err := hex.DecodeString(req.URL.Query().Get("hash"))
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var address FabricationDataHash
+	if len(hash) != len(address) {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	copy(address[:], hash)
+
+	nodeID, err := strconv.ParseUint(req.URL.Query().Get("node"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	grants := make([]*GrantData, 0)
+	for _, grant := range a.Node.cb.state.provenance(address, NodeID(nodeID)) {
+		grants = append(grants, &GrantData{
+			ID:        grant.ID,
+			From:      grant.From,
+			To:        grant.To,
+			Count:     grant.Count,
+			Transfer:  grant.Transfer,
+			Timestamp: formatLedgerTime(grant.Timestamp),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(grants)
 
-	err := req.ParseMultipartForm(4 * 1024 * 1024)
 	if err != nil {
 		a.Node.app.logger.Error(err)
+	}
+}
+
+// estimatedLedgerTime returns the ledger time a request submitted now is likely checked at.
+// Committed ledger time only advances with new batches, the ledger enforces validity on its own.
+func estimatedLedgerTime(committed int64) int64 {
+	if now := time.Now().Unix(); now > committed {
+		return now
+	}
+	return committed
+}
+
+func formatLedgerTime(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
+
+// parseValidity parses an optional form value given as date or RFC 3339 time, 0 if empty
+func parseValidity(form map[string][]string, key string) (int64, error) {
+	values := form[key]
+	if len(values) == 0 || values[0] == "" {
+		return 0, nil
+	}
+	if len(values) != 1 {
+		return 0, fmt.Errorf("multiple values for %v", key)
+	}
+
+	t, err := time.Parse("2006-01-02", values[0])
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, values[0])
+	}
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// parseWait parses the optional form value wait, giving the seconds to wait for submitted requests to be committed
+func parseWait(form map[string][]string) (time.Duration, error) {
+	values := form["wait"]
+	if len(values) == 0 || values[0] == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxSubmissionWait {
+		wait = maxSubmissionWait
+	}
+	return wait, nil
+}
+
+// submissionStatus returns the status of the given requests submitted through this node,
+// waiting up to the given duration in total for them to be committed
+func (a *APIServer) submissionStatus(requestIDs []string, wait time.Duration) []*TxStatus {
+	deadline := time.Now().Add(wait)
+
+	statuses := make([]*TxStatus, 0, len(requestIDs))
+	for _, id := range requestIDs {
+		var status *TxStatus
+		if remaining := time.Until(deadline); remaining > 0 {
+			// Errors are reported through the status
+			status, _ = a.Node.app.submissions.wait(id, remaining)
+		} else {
+			status, _ = a.Node.app.submissions.status(id)
+		}
+		if status == nil {
+			status = &TxStatus{ID: id, Status: TxPending}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Maximum size of a form value preceding the uploaded file
+const maxFormValueSize = 64 * 1024
+
+// AddFile encrypts an uploaded file into the store and allows the selected nodes to fabricate it.
+// The file part uploadFile must follow the other form values, so the request is validated
+// before any data is stored.
+func (a *APIServer) AddFile(w http.ResponseWriter, req *http.Request) {
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	form := make(map[string][]string)
+	var uploadFile *multipart.Part
+	for uploadFile == nil {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() == "uploadFile" {
+			uploadFile = part
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFormValueSize+1))
+		if err != nil || len(value) > maxFormValueSize {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		form[part.FormName()] = append(form[part.FormName()], string(value))
+	}
+
+	if uploadFile == nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	partCountList := req.MultipartForm.Value["partCount"]
+	partCountList := form["partCount"]
 	if len(partCountList) != 1 {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	partCount, err := strconv.ParseUint(partCountList[0], 10, 64)
+	partCount, err := strconv.ParseUint(partCountList[0], 10, 32)
 	if err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	nodeListForm := req.MultipartForm.Value["selectNode"]
+	nodeListForm := form["selectNode"]
 	if len(nodeListForm) == 0 {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
+	// Optional validity window, validUntil is exclusive
+	validFrom, err := parseValidity(form, "validFrom")
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	validUntil, err := parseValidity(form, "validUntil")
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	wait, err := parseWait(form)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
 	var nodeList []NodeID
 	for _, v := range nodeListForm {
 		nodeID, err := strconv.ParseUint(v, 10, 64)
@@ -143,84 +424,141 @@ func (a *APIServer) AddFile(w http.ResponseWriter, req *http.Request) {
 
 	// Valid request
 
-	file := req.MultipartForm.File["uploadFile"]
-
-	if len(file) != 1 {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	hash, key, err := a.Node.app.storeEncrypted(uploadFile)
+	if err != nil {
+		a.Node.app.logger.Error(err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
-	uploadFile, err := file[0].Open()
+	requestIDs, err := a.Node.app.submitFile(hash, key, nodeList, uint32(partCount), validFrom, validUntil)
 	if err != nil {
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	fileData, err := io.ReadAll(uploadFile)
+	a.encodeJSON(w, &SubmissionData{
+		FileHash: fmt.Sprintf("%x", hash),
+		Requests: a.submissionStatus(requestIDs, wait),
+	})
+
+}
+
+// RevokeFabrication withdraws the allowance of a node for a file originating at this node
+func (a *APIServer) RevokeFabrication(w http.ResponseWriter, req *http.Request) {
+	a.changeAllowance(w, req, RevokeFabrication)
+}
+
+// AdjustFabrication sets the remaining part count of a node for a file originating at this node
+func (a *APIServer) AdjustFabrication(w http.ResponseWriter, req *http.Request) {
+	a.changeAllowance(w, req, AdjustFabrication)
+}
+
+// TransferFabrication passes part of the remaining allowance of this node to another node
+func (a *APIServer) TransferFabrication(w http.ResponseWriter, req *http.Request) {
+	a.changeAllowance(w, req, TransferFabrication)
+}
+
+func (a *APIServer) changeAllowance(w http.ResponseWriter, req *http.Request, requestType RequestType) {
+	err := req.ParseMultipartForm(4 * 1024 * 1024)
 	if err != nil {
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	// TODO factor out the following routine for clarity?
+	partSelectList := req.MultipartForm.Value["partSelect"]
+	if len(partSelectList) != 1 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
 
-	hash, err := a.Node.app.Store.StoreData(fileData)
+	hash, err := hex.DecodeString(partSelectList[0])
 	if err != nil {
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	binaryOriginID := make([]byte, 8)
-	binary.LittleEndian.PutUint64(binaryOriginID, uint64(a.Node.id))
+	var address FabricationDataHash
+	if len(hash) != len(address) {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	copy(address[:], hash)
 
-	var payload []byte
-	payload = append(payload, hash[:]...)
-	payload = append(payload, binaryOriginID...)
+	nodeListForm := req.MultipartForm.Value["selectNode"]
+	if len(nodeListForm) != 1 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
 
-	reqID, err := uuid.GenerateUUID()
+	nodeID, err := strconv.ParseUint(nodeListForm[0], 10, 64)
 	if err != nil {
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	a.Node.app.Submit(Request{
-		ClientID: fmt.Sprintf("node-%v", a.Node.id),
-		ID:       reqID,
-		Type:     AddFile,
-		Payload:  payload,
-	})
-
-	for _, node := range nodeList {
-		binaryAllowedID := make([]byte, 8)
-		binary.LittleEndian.PutUint64(binaryAllowedID, uint64(node))
+	wait, err := parseWait(req.MultipartForm.Value)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
 
-		var payload []byte
-		payload = append(payload, hash[:]...)
-		payload = append(payload, binaryAllowedID...)
+	var partCount uint64
+	if requestType == AdjustFabrication || requestType == TransferFabrication {
+		partCountList := req.MultipartForm.Value["partCount"]
+		if len(partCountList) != 1 {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
 
-		reqID, err = uuid.GenerateUUID()
+		partCount, err = strconv.ParseUint(partCountList[0], 10, 32)
 		if err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
+	}
 
-		a.Node.app.Submit(Request{
-			ClientID: fmt.Sprintf("node-%v", a.Node.id),
-			ID:       reqID,
-			Type:     AllowFabrication,
-			Payload:  payload,
-			Count:    int(partCount),
-		})
+	var payload isTransactionPayload_Payload
+	switch requestType {
+	case RevokeFabrication:
+		payload = &TransactionPayload_RevokeFabrication{RevokeFabrication: &RevokeFabricationPayload{
+			FileHash:    address[:],
+			AllowedNode: nodeID,
+		}}
+	case AdjustFabrication:
+		payload = &TransactionPayload_AdjustFabrication{AdjustFabrication: &AdjustFabricationPayload{
+			FileHash:    address[:],
+			AllowedNode: nodeID,
+			Remaining:   uint32(partCount),
+		}}
+	case TransferFabrication:
+		// The receiving node needs the file key to fabricate
+		wrappedKey, err := a.Node.app.rewrapFileKey(address, NodeID(nodeID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		payload = &TransactionPayload_TransferFabrication{TransferFabrication: &TransferFabricationPayload{
+			FileHash:      address[:],
+			ReceivingNode: nodeID,
+			Count:         uint32(partCount),
+			WrappedKey:    wrappedKey,
+		}}
 	}
 
-	fmt.Fprintf(w, "Stored data with hash %x, payload %x for nodes %v", hash, payload, nodeList)
+	reqID, err := a.Node.app.submitPayload(requestType, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
+	a.encodeJSON(w, a.submissionStatus([]string{reqID}, wait)[0])
 }
 
 func (a *APIServer) Fabricate(w http.ResponseWriter, req *http.Request) {
 	err := req.ParseMultipartForm(4 * 1024 * 1024)
 	if err != nil {
-		a.Node.app.logger.Error(err)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
@@ -243,65 +581,256 @@ func (a *APIServer) Fabricate(w http.ResponseWriter, req *http.Request) {
 	}
 	copy(address[:], hash)
 
-	fabricationData, err := a.Node.app.Store.GetData(address)
+	if _, err := a.Node.app.fileKey(address); err != nil {
+		http.Error(w, fmt.Sprintf("Data for hash %x cannot be decrypted by this node", address), http.StatusForbidden)
+		return
+	}
+
+	// Opening only reads the first chunk, the data is streamed once fabrication is confirmed
+	fabricationData, err := a.Node.app.openDecrypted(address)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Data not found for hash %x", address), http.StatusNotFound)
 		return
 	}
+	started := false
+	defer func() {
+		if !started {
+			fabricationData.Close()
+		}
+	}()
+
+	a.Node.cb.state.RLock()
+	allowance := a.Node.cb.state.allowCount(address, a.Node.id)
+	allowed := allowance != nil && allowance.RemainingCount > 0
+	valid := allowance != nil && allowance.validAt(estimatedLedgerTime(a.Node.cb.state.time))
+	a.Node.cb.state.RUnlock()
+
+	if !allowed {
+		http.Error(w, fmt.Sprintf("No fabrication allowance remaining for hash %x", address), http.StatusForbidden)
+		return
+	}
+	if !valid {
+		http.Error(w, fmt.Sprintf("Fabrication allowance for hash %x is expired or not yet valid", address), http.StatusForbidden)
+		return
+	}
+
+	// Reserve one part on the ledger before starting fabrication
+	announceID, err := uuid.GenerateUUID()
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = a.Node.app.SubmitAndWait(Request{
+		ClientID: fmt.Sprintf("node-%v", a.Node.id),
+		ID:       announceID,
+		Type:     AnnounceFabrication,
+		Payload: newPayload(&TransactionPayload_AnnounceFabrication{AnnounceFabrication: &AnnounceFabricationPayload{
+			FileHash: address[:],
+			Count:    1,
+		}}),
+	}, fabricationCommitTimeout)
+	if errors.Is(err, ErrCommitTimeout) {
+		// The announcement may still be committed, release its part since fabrication does not start
+		go func() {
+			if a.awaitReservation(announceID) {
+				a.closeReservation(address, announceID, 1)
+			}
+		}()
+		http.Error(w, "Fabrication was not confirmed by the ledger in time", http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
 	// TODO refactor long running communication into  another module!
+	started = true
 	go func() {
-		fabricationConn, err := net.Dial("udp", a.FabricationEndpoint)
+		defer fabricationData.Close()
+
+		// The simulator receives the same stream as the printer
+		printerData, simulate := teeSimulation(fabricationData)
+
+		notProduced := uint32(0)
+		commandsSent, err := a.sendToPrinter(printerData)
 		if err != nil {
 			a.Node.app.logger.Error("Communication error: ", err)
-			http.Error(w, "Communication error", http.StatusInternalServerError)
+			notProduced = 1
+		}
+
+		// Close reservation, releasing the part if fabrication failed
+		a.closeReservation(address, announceID, notProduced)
+
+		// Record job outcome with simulated quality evidence for auditing by the originating node
+		receipt := FabricationReceipt{
+			FileHash:     address[:],
+			AnnounceID:   announceID,
+			Success:      notProduced == 0,
+			CommandsSent: int64(commandsSent),
+		}
+		printer, err := simulate()
+		if err != nil {
+			a.Node.app.logger.Warn("Failed to simulate fabrication data: ", err)
+		} else {
+			receipt.Simulation = newSimulationSummary(printer)
+		}
+		receipt.Digest = receipt.Simulation.Digest()
+
+		receiptID, err := uuid.GenerateUUID()
+		if err != nil {
+			a.Node.app.logger.Error(err)
 			return
 		}
 
-		scannerOut := bufio.NewScanner(bytes.NewReader(fabricationData))
-		scannerIn := bufio.NewScanner(fabricationConn)
+		err = a.Node.app.Submit(Request{
+			ClientID: fmt.Sprintf("node-%v", a.Node.id),
+			ID:       receiptID,
+			Type:     FabricationCompleted,
+			Payload: newPayload(&TransactionPayload_FabricationCompleted{
+				FabricationCompleted: receipt.toPayload(),
+			}),
+		})
+		if err != nil {
+			a.Node.app.logger.Error("Failed to submit fabrication receipt: ", err)
+		}
+	}()
+
+	fmt.Fprint(w, "Started fabrication")
 
-		// optionally, resize scanner's capacity for lines over 64K, see next example
-		for scannerOut.Scan() {
-			// Write command
-			command := bytes.TrimSpace(scannerOut.Bytes())
-			if len(command) == 0 {
-				continue
-			}
-			if command[0] == byte(';') {
-				continue
-			}
+}
 
-			a.Node.app.logger.Debugf("Sending command to printer: %s", command)
-			n, err := fabricationConn.Write(command)
-			if n != len(command) || err != nil {
-				a.Node.app.logger.Error("Communication error: ", err)
-				return
-			}
+// awaitReservation waits until the announcement submitted through this node is committed
+// or dropped from the request pool, and reports whether it left an open reservation
+func (a *APIServer) awaitReservation(announceID string) bool {
+	// Pending requests are reported as rejected once the pool timeout passed
+	if _, err := a.Node.app.submissions.wait(announceID, a.Node.app.submissions.poolTimeout); err != nil {
+		a.Node.app.logger.Debugf("Announcement %v not applied: %v", announceID, err)
+		return false
+	}
 
-			// Wait for status
-			if scannerIn.Scan() {
-				response := scannerIn.Text()
-				a.Node.app.logger.Debug("Got response from printer:", response)
-				responseSplit := strings.Split(response, " ")
-				if len(responseSplit) < 1 {
-					a.Node.app.logger.Error("Communication error: Response too short")
-					return
-				}
-				if responseSplit[0] != "ok" {
-					a.Node.app.logger.Errorf("Communication error: Response not ok, got '%v'", response)
-					return
-				}
-			}
+	a.Node.cb.state.RLock()
+	defer a.Node.cb.state.RUnlock()
+	reservation := a.Node.cb.state.reservation(announceID)
+	return reservation != nil && !reservation.Closed
+}
 
+// closeReservation submits the CancelFabrication request closing the given reservation.
+// Failed submissions are retried with backoff until the reservation is closed on the ledger,
+// otherwise the reserved parts would never be released.
+func (a *APIServer) closeReservation(address FabricationDataHash, announceID string, notProduced uint32) {
+	backoff := cancelBackoffBase
+	for attempt := 1; ; attempt++ {
+		cancelID, err := uuid.GenerateUUID()
+		if err == nil {
+			err = a.Node.app.SubmitAndWait(Request{
+				ClientID: fmt.Sprintf("node-%v", a.Node.id),
+				ID:       cancelID,
+				Type:     CancelFabrication,
+				Payload: newPayload(&TransactionPayload_CancelFabrication{CancelFabrication: &CancelFabricationPayload{
+					FileHash:    address[:],
+					AnnounceId:  announceID,
+					NotProduced: notProduced,
+				}}),
+			}, fabricationCommitTimeout)
 		}
 
-		if err := scannerOut.Err(); err != nil {
-			a.Node.app.logger.Error("Communication error: ", err)
+		// Earlier attempts may have been committed after timing out
+		a.Node.cb.state.RLock()
+		reservation := a.Node.cb.state.reservation(announceID)
+		closed := reservation == nil || reservation.Closed
+		a.Node.cb.state.RUnlock()
+		if closed {
 			return
 		}
+
+		a.Node.app.logger.Warnf("Failed to close fabrication reservation %v (attempt %d): %v", announceID, attempt, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > cancelBackoffMax {
+			backoff = cancelBackoffMax
+		}
+	}
+}
+
+// teeSimulation returns a reader passing the fabrication data through to the G-code simulator.
+// The returned function feeds the data not read yet to the simulator and returns its result.
+func teeSimulation(fabricationData io.Reader) (io.Reader, func() (*gcodesim.Printer, error)) {
+	type simulation struct {
+		printer *gcodesim.Printer
+		err     error
+	}
+
+	simReader, simWriter := io.Pipe()
+	done := make(chan simulation, 1)
+	go func() {
+		printer, err := gcodesim.Simulate(simReader)
+		// Keep consuming, the printer must not be blocked by a failed simulation
+		io.Copy(io.Discard, simReader)
+		done <- simulation{printer, err}
 	}()
 
-	fmt.Fprint(w, "Started fabrication")
+	tee := io.TeeReader(fabricationData, simWriter)
+	return tee, func() (*gcodesim.Printer, error) {
+		_, err := io.Copy(io.Discard, tee)
+		simWriter.CloseWithError(err)
+		result := <-done
+		if err != nil {
+			return nil, err
+		}
+		return result.printer, result.err
+	}
+}
+
+// sendToPrinter streams the G-code commands to the fabrication endpoint, waiting for acknowledgement of every command
+// Returns the number of commands sent
+func (a *APIServer) sendToPrinter(fabricationData io.Reader) (int, error) {
+	fabricationConn, err := net.Dial("udp", a.FabricationEndpoint)
+	if err != nil {
+		return 0, err
+	}
+	defer fabricationConn.Close()
+
+	var sent int
+
+	scannerOut := bufio.NewScanner(fabricationData)
+	scannerIn := bufio.NewScanner(fabricationConn)
+
+	// optionally, resize scanner's capacity for lines over 64K, see next example
+	for scannerOut.Scan() {
+		// Write command
+		command := bytes.TrimSpace(scannerOut.Bytes())
+		if len(command) == 0 {
+			continue
+		}
+		if command[0] == byte(';') {
+			continue
+		}
+
+		a.Node.app.logger.Debugf("Sending command to printer: %s", command)
+		n, err := fabricationConn.Write(command)
+		if err != nil {
+			return sent, err
+		}
+		if n != len(command) {
+			return sent, io.ErrShortWrite
+		}
+		sent++
+
+		// Wait for status
+		if scannerIn.Scan() {
+			response := scannerIn.Text()
+			a.Node.app.logger.Debug("Got response from printer:", response)
+			responseSplit := strings.Split(response, " ")
+			if len(responseSplit) < 1 {
+				return sent, errors.New("response too short")
+			}
+			if responseSplit[0] != "ok" {
+				return sent, fmt.Errorf("response not ok, got '%v'", response)
+			}
+		}
+
+	}
 
+	return sent, scannerOut.Err()
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAwaitReservation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		requests func(announce *Request) []*Request
+		open     bool
+	}{
+		{
+			name:     "committed",
+			requests: func(announce *Request) []*Request { return []*Request{testAllow(t, 1, 2, 5, testUnbounded), announce} },
+			open:     true,
+		},
+		{
+			name: "closed",
+			requests: func(announce *Request) []*Request {
+				return []*Request{testAllow(t, 1, 2, 5, testUnbounded), announce, testCancel(t, 2, announce.ID, 1)}
+			},
+		},
+		{
+			// Skipped without allowance
+			name:     "not applied",
+			requests: func(announce *Request) []*Request { return []*Request{announce} },
+		},
+		{
+			name:     "dropped from pool",
+			requests: func(*Request) []*Request { return nil },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(t, newTestCA(t), 2, 1, 2)
+			app.submissions = newSubmissionTracker(50 * time.Millisecond)
+			api := &APIServer{Node: app.Node}
+			commitTestRecord(t, app, 1000, testAddFile(t, 1))
+
+			announce := testAnnounce(t, 2, 3)
+			app.submissions.track(announce.ID)
+
+			// The announcement is committed after the fabrication request timed out
+			open := make(chan bool)
+			go func() { open <- api.awaitReservation(announce.ID) }()
+			if requests := tc.requests(announce); len(requests) > 0 {
+				commitTestRecord(t, app, 1001, requests...)
+			}
+
+			if result := <-open; result != tc.open {
+				t.Fatalf("Open reservation %v, expected %v", result, tc.open)
+			}
+		})
+	}
+}
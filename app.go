@@ -7,16 +7,16 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"crypto/ed25519"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -24,8 +24,8 @@ import (
 	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
 	"github.com/SmartBFT-Go/consensus/v2/pkg/wal"
 	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"github.com/hashicorp/go-uuid"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -49,6 +49,9 @@ var fastConfig = types.Configuration{
 	RequestPoolSubmitTimeout:      5 * time.Second,
 }
 
+// proposalTimeTolerance bounds the deviation of proposal timestamps from the local clock
+const proposalTimeTolerance = 30 * time.Second
+
 // App implements all interfaces required by an application using this library
 type App struct {
 	ID              NodeID
@@ -65,11 +68,14 @@ type App struct {
 	logger          *zap.SugaredLogger
 	lastRecord      lastRecord
 	verificationSeq uint64
+	syncer          *synchronizer
+	submissions     *submissionTracker
 
 	// Signature Data
 	nodeCert *x509.Certificate
 	nodeKey  ed25519.PrivateKey
 	caCert   *x509.CertPool
+	certDir  string // certificates of other nodes, for encrypting file keys
 
 	// Fabrication Data Storage
 	Store FabricationDataStore
@@ -80,91 +86,107 @@ type lastRecord struct {
 	signatures []types.Signature
 }
 
-// Submit submits the client request
-func (a *App) Submit(req Request) {
-	a.Consensus.SubmitRequest(req.ToBytes())
-}
-
-// Sync synchronizes and returns the latest decision
-func (a *App) Sync() types.SyncResponse {
+// Submit validates, signs and submits the client request
+// Returns the reason if the request is rejected, the outcome is tracked until the request is committed
+func (a *App) Submit(req Request) error {
+	a.submissions.track(req.ID)
 
-	reconfigSync := types.ReconfigSync{InReplicatedDecisions: false}
-
-	// TODO enhance experimental implementation, currently chooses first live node which is not itself
-	// also has to implement:
-	// further verifications (client signatures, etc)
-	// load balancing
-	// retries
-
-	a.Node.Lock()
-	var srcConn *grpc.ClientConn
-	for id, nodeConn := range a.Node.nodeChannels {
-		if id == a.ID {
-			continue
-		}
-		srcConn = nodeConn
-		a.logger.Debugf("Sync connection with node %v", id)
-		break
+	if err := newRequestValidator(a.Node.cb).validate(&req, true); err != nil {
+		a.submissions.reject(req.ID, err)
+		return fmt.Errorf("request %v rejected: %w", req.ID, err)
 	}
-	a.Node.Unlock()
 
-	if srcConn == nil {
-		//Single node started
-		return types.SyncResponse{Latest: *a.lastDecision, Reconfig: reconfigSync}
+	req.Certificate = a.nodeCert.Raw
+	req.Signature = ed25519.Sign(a.nodeKey, req.signedBytes())
+	if err := a.Consensus.SubmitRequest(req.ToBytes()); err != nil {
+		a.submissions.reject(req.ID, err)
+		return err
 	}
+	return nil
+}
 
-	client := NewNodeExchangeClient(srcConn)
-
-	fetchFrom := &BlockPosition{
-		ViewId:         a.Consensus.Metadata.GetViewId(),
-		LatestSequence: a.Consensus.Metadata.LatestSequence,
+// SubmitAndWait submits the client request and waits until it is committed or the timeout expires
+func (a *App) SubmitAndWait(req Request, timeout time.Duration) error {
+	if err := a.Submit(req); err != nil {
+		return err
 	}
 
-	stream, err := client.FetchBlocks(context.TODO(), fetchFrom)
+	_, err := a.submissions.wait(req.ID, timeout)
+	return err
+}
+
+// submitPayload submits a request carrying the given payload on behalf of this node
+// Returns the request ID
+func (a *App) submitPayload(requestType RequestType, payload isTransactionPayload_Payload) (string, error) {
+	reqID, err := uuid.GenerateUUID()
 	if err != nil {
-		// TODO implement retry logic instead of panicking
-		a.logger.Panicln(err)
+		return "", err
 	}
 
-	for {
-		record, err := stream.Recv()
-		if err == io.EOF {
-			err = stream.CloseSend()
-			if err != nil {
-				a.logger.Panic(err)
+	return reqID, a.Submit(Request{
+		ClientID: fmt.Sprintf("node-%v", a.ID),
+		ID:       reqID,
+		Type:     requestType,
+		Payload:  newPayload(payload),
+	})
+}
+
+// submitFile registers stored fabrication data originating at this node and grants the given nodes
+// count parts each. Files already owned by this node only receive additional allowances.
+// The file key is encrypted to this node and each granted node.
+// Stored data of a file unknown to the ledger is deleted if no request was submitted.
+// Returns the IDs of the submitted requests
+func (a *App) submitFile(hash FabricationDataHash, key []byte, nodes []NodeID, count uint32, validFrom, validUntil int64) (requestIDs []string, err error) {
+	owner, known := a.Node.cb.state.owner(hash)
+	defer func() {
+		if err != nil && len(requestIDs) == 0 && !known {
+			if deleteErr := a.Store.DeleteData(hash); deleteErr != nil {
+				a.logger.Warnf("Failed to delete data %x of unsubmitted file: %v", hash, deleteErr)
 			}
-			break
 		}
+	}()
 
+	if !known || owner != a.ID {
+		wrappedKey, err := a.wrapFileKey(hash, key, a.ID)
 		if err != nil {
-			a.logger.Panic(err)
+			return requestIDs, err
 		}
-
-		batchPayload, err := asn1.Marshal(struct{ Requests [][]byte }{Requests: record.Batch})
+		reqID, err := a.submitPayload(AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+			FileHash:   hash[:],
+			OriginNode: uint64(a.ID),
+			WrappedKey: wrappedKey,
+		}})
 		if err != nil {
-			a.logger.Panic(err)
+			return requestIDs, err
 		}
+		requestIDs = append(requestIDs, reqID)
+	}
 
-		proposal := types.Proposal{
-			Payload:  batchPayload,
-			Metadata: record.Metadata,
+	for _, node := range nodes {
+		wrappedKey, err := a.wrapFileKey(hash, key, node)
+		if err != nil {
+			return requestIDs, fmt.Errorf("encrypting file key for node %d: %w", node, err)
 		}
-		a.Deliver(proposal, nil)
-		for _, req := range record.Batch {
-			request := requestFromBytes(req)
-			if request.Reconfig.InLatestDecision {
-				reconfig := request.Reconfig.recconfigToUint(a.ID)
-				reconfigSync = types.ReconfigSync{
-					InReplicatedDecisions: true,
-					CurrentNodes:          reconfig.CurrentNodes,
-					CurrentConfig:         reconfig.CurrentConfig,
-				}
-			}
+		reqID, err := a.submitPayload(AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+			FileHash:    hash[:],
+			AllowedNode: uint64(node),
+			Count:       count,
+			ValidFrom:   validFrom,
+			ValidUntil:  validUntil,
+			WrappedKey:  wrappedKey,
+		}})
+		if err != nil {
+			return requestIDs, err
 		}
-
+		requestIDs = append(requestIDs, reqID)
 	}
 
-	return types.SyncResponse{Latest: *a.lastDecision, Reconfig: reconfigSync}
+	return requestIDs, nil
+}
+
+// Sync synchronizes and returns the latest decision
+func (a *App) Sync() types.SyncResponse {
+	return a.syncer.Sync()
 }
 
 // RequestID returns info about the given request
@@ -178,16 +200,50 @@ func (a *App) RequestID(req []byte) types.RequestInfo {
 
 // VerifyProposal verifies the given proposal and returns the included requests
 func (a *App) VerifyProposal(proposal types.Proposal) ([]types.RequestInfo, error) {
+	if _, err := a.verifyHeader(proposal); err != nil {
+		return nil, err
+	}
+
 	blockData := batchFromBytes(proposal.Payload)
 	requests := make([]types.RequestInfo, 0)
 	for _, t := range blockData.Requests {
-		req := requestFromBytes(t)
+		req, err := parseRequest(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.verifyClientSignature(req); err != nil {
+			return nil, fmt.Errorf("request %v from %v: %w", req.ID, req.ClientID, err)
+		}
+		// Requests conflicting with the ledger state are committed without effect, see AssembleProposal
+		if err := req.validate(); err != nil {
+			return nil, fmt.Errorf("request %v from %v: %w", req.ID, req.ClientID, err)
+		}
 		reqInfo := types.RequestInfo{ID: req.ID, ClientID: req.ClientID}
 		requests = append(requests, reqInfo)
 	}
 	return requests, nil
 }
 
+// verifyHeader checks that the ledger time assigned by the leader does not move backwards
+// and is close to our own clock
+func (a *App) verifyHeader(proposal types.Proposal) (*blockHeader, error) {
+	header, err := headerFromBytes(proposal.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proposal header: %w", err)
+	}
+
+	if previous := a.Node.cb.state.Time(); header.Timestamp < previous {
+		return nil, fmt.Errorf("proposal timestamp %d precedes ledger time %d", header.Timestamp, previous)
+	}
+
+	skew := time.Since(time.Unix(header.Timestamp, 0))
+	if skew > proposalTimeTolerance || skew < -proposalTimeTolerance {
+		return nil, fmt.Errorf("proposal timestamp %d deviates %v from local clock", header.Timestamp, skew)
+	}
+
+	return header, nil
+}
+
 // RequestsFromProposal returns from the given proposal the included requests' info
 func (a *App) RequestsFromProposal(proposal types.Proposal) []types.RequestInfo {
 	blockData := batchFromBytes(proposal.Payload)
@@ -202,10 +258,70 @@ func (a *App) RequestsFromProposal(proposal types.Proposal) []types.RequestInfo
 
 // VerifyRequest verifies the given request and returns its info
 func (a *App) VerifyRequest(val []byte) (types.RequestInfo, error) {
-	req := requestFromBytes(val)
+	req, err := parseRequest(val)
+	if err != nil {
+		return types.RequestInfo{}, err
+	}
+
+	if err := a.verifyClientSignature(req); err != nil {
+		return types.RequestInfo{}, fmt.Errorf("request %v from %v: %w", req.ID, req.ClientID, err)
+	}
+
+	if err := newRequestValidator(a.Node.cb).validate(req, true); err != nil {
+		return types.RequestInfo{}, fmt.Errorf("request %v from %v: %w", req.ID, req.ClientID, err)
+	}
+
 	return types.RequestInfo{ID: req.ID, ClientID: req.ClientID}, nil
 }
 
+// verifyClientSignature checks that the request was signed by a node certificate issued by our CA,
+// matching the node given as ClientID or a consenter for reconfig requests
+func (a *App) verifyClientSignature(req *Request) error {
+	if len(req.Signature) == 0 || len(req.Certificate) == 0 {
+		return errors.New("missing client signature")
+	}
+
+	cert, err := x509.ParseCertificate(req.Certificate)
+	if err != nil {
+		return err
+	}
+
+	opts := x509.VerifyOptions{
+		Roots: a.caCert,
+	}
+
+	if _, err := cert.Verify(opts); err != nil {
+		return errors.New("failed to verify certificate: " + err.Error())
+	}
+
+	if cert.PublicKeyAlgorithm != x509.Ed25519 {
+		return errors.New("unexpected public key algorithm")
+	}
+
+	pub, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return errors.New("unexpected public key type")
+	}
+
+	// Reconfig requests change the consensus membership and are only accepted from consenters.
+	// The leader submitting them when discovering nodes may have changed by the time they are verified.
+	// All other ClientIDs identify the originating node.
+	// TODO standardize CN formatting
+	if req.ClientID == reconfigClientID {
+		if !a.isConsenter(cert.Subject.CommonName) {
+			return errors.New("reconfig not signed by a consenter")
+		}
+	} else if cert.Subject.CommonName != strings.Replace(req.ClientID, "-", "", 1) {
+		return errors.New("unexpected signer common name")
+	}
+
+	if !ed25519.Verify(pub, req.signedBytes(), req.Signature) {
+		return errors.New("invalid request signature")
+	}
+
+	return nil
+}
+
 // VerifyConsenterSig verifies a nodes signature on the given proposal
 // Returns auxiliary data and error
 func (a *App) VerifyConsenterSig(signature types.Signature, proposal types.Proposal) ([]byte, error) {
@@ -269,6 +385,64 @@ func (a *App) VerifyConsenterSig(signature types.Signature, proposal types.Propo
 	return sigMsg.AuxiliaryData, nil
 }
 
+// consenters returns the nodes deciding the block following our latest committed block.
+// The runtime peer view may lag behind or exceed them.
+func (a *App) consenters() []uint64 {
+	if nodes, ok := a.Node.cb.consenterSet(); ok {
+		return nodes
+	}
+	// Before the first reconfig, consensus runs with the nodes it was started with
+	return a.Node.Nodes()
+}
+
+// isConsenter reports whether the certificate common name belongs to a consenter
+func (a *App) isConsenter(commonName string) bool {
+	for _, node := range a.consenters() {
+		if commonName == fmt.Sprintf("node%v", node) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyQuorum checks that the given proposal, following our latest committed block,
+// was signed by at least 2f+1 distinct consenters
+func (a *App) verifyQuorum(proposal types.Proposal, signatures []types.Signature) error {
+	nodes := a.consenters()
+	f := (len(nodes) - 1) / 3
+	quorum := 2*f + 1
+
+	signers := make(map[uint64]bool)
+	for _, sig := range signatures {
+		if signers[sig.ID] {
+			continue
+		}
+
+		isConsenter := false
+		for _, node := range nodes {
+			if node == sig.ID {
+				isConsenter = true
+				break
+			}
+		}
+		if !isConsenter {
+			continue
+		}
+
+		if _, err := a.VerifyConsenterSig(sig, proposal); err != nil {
+			a.logger.Warnf("Invalid consenter signature from node %d: %v", sig.ID, err)
+			continue
+		}
+		signers[sig.ID] = true
+	}
+
+	if len(signers) < quorum {
+		return fmt.Errorf("got %d valid consenter signatures, need %d", len(signers), quorum)
+	}
+
+	return nil
+}
+
 func (a *App) AuxiliaryData(msg []byte) []byte {
 	sigMsg := &AppSignedMessage{}
 	rest, err := asn1.Unmarshal(msg, sigMsg)
@@ -391,9 +565,20 @@ func (a *App) SignProposal(proposal types.Proposal, aux []byte) *types.Signature
 }
 
 // AssembleProposal assembles a new proposal from the given requests
+// Requests which conflict with the ledger state since submission are included as well. They are committed
+// without effect, since ledgerState.apply skips them deterministically, and the submitter learns the reason
+// from the submission status. Leaving them out would keep them in the request pool until they time out,
+// while the leader keeps proposing empty batches and followers complain about the pending requests.
 func (a *App) AssembleProposal(metadata []byte, requests [][]byte) types.Proposal {
+	// Ledger time never moves backwards, even if our clock does
+	header := &blockHeader{Timestamp: time.Now().Unix()}
+	if previous := a.Node.cb.state.Time(); header.Timestamp < previous {
+		header.Timestamp = previous
+	}
+
 	return types.Proposal{
 		VerificationSequence: int64(atomic.LoadUint64(&a.verificationSeq)),
+		Header:               header.toBytes(),
 		Payload:              batch{Requests: requests}.toBytes(),
 		Metadata:             metadata,
 	}
@@ -412,10 +597,13 @@ func (a *App) Deliver(proposal types.Proposal, signatures []types.Signature) typ
 		}
 	}()
 	record := &AppRecord{
-		Metadata: proposal.Metadata,
-		Batch:    batchFromBytes(proposal.Payload),
+		Metadata:   proposal.Metadata,
+		Batch:      batchFromBytes(proposal.Payload),
+		Proposal:   proposal,
+		Signatures: signatures,
 	}
 	a.Node.cb.add(record)
+	a.resolveSubmissions(record)
 	a.lastDecision = &types.Decision{
 		Proposal:   proposal,
 		Signatures: signatures,
@@ -436,7 +624,7 @@ func (a *App) Deliver(proposal types.Proposal, signatures []types.Signature) typ
 
 	for _, req := range record.Batch.Requests {
 		request := requestFromBytes(req)
-		if request.Reconfig.InLatestDecision {
+		if request.isReconfig() {
 			reconfig := request.Reconfig.recconfigToUint(a.ID)
 			return types.Reconfig{InLatestDecision: true, CurrentNodes: reconfig.CurrentNodes, CurrentConfig: reconfig.CurrentConfig}
 		}
@@ -445,7 +633,27 @@ func (a *App) Deliver(proposal types.Proposal, signatures []types.Signature) typ
 	return types.Reconfig{InLatestDecision: false}
 }
 
-func newNode(id NodeID, walDir string, tlsPaths TLSPaths, rotateLeader bool, decisionsPerLeader uint64) *App {
+// resolveSubmissions updates the status of requests submitted through this node which were committed with the record
+func (a *App) resolveSubmissions(record *AppRecord) {
+	md := &smartbftprotos.ViewMetadata{}
+	if err := proto.Unmarshal(record.Metadata, md); err != nil {
+		a.logger.Panic(err)
+	}
+
+	for _, reqBytes := range record.Batch.Requests {
+		request, err := parseRequest(reqBytes)
+		if err != nil {
+			continue
+		}
+		if reason, skipped := a.Node.cb.skipReason(request.ID); skipped {
+			a.submissions.resolve(request.ID, TxRejected, "committed but not applied: "+reason, md.LatestSequence)
+			continue
+		}
+		a.submissions.resolve(request.ID, TxCommitted, "", md.LatestSequence)
+	}
+}
+
+func newNode(id NodeID, dataDir string, tlsPaths TLSPaths, rotateLeader bool, decisionsPerLeader uint64) *App {
 	logConfig := zap.NewDevelopmentConfig()
 	//logConfig := zap.NewProductionConfig()
 	logger, _ := logConfig.Build()
@@ -454,7 +662,27 @@ func newNode(id NodeID, walDir string, tlsPaths TLSPaths, rotateLeader bool, dec
 
 	cert, caPool, key, err := loadCertificate(tlsPaths)
 	if err != nil {
-		sugaredLogger.Panicf("Failed to initialize WAL: %s", err)
+		sugaredLogger.Panicf("Failed to load certificate: %s", err)
+	}
+
+	blockStore, err := OpenFileBlockStore(filepath.Join(dataDir, nodeName, "ledger"))
+	if err != nil {
+		sugaredLogger.Panicf("Failed to open block store: %s", err)
+	}
+
+	// Fabrication data is kept on disk next to the ledger, files may exceed available memory
+	fileDir, err := filepath.Abs(filepath.Join(dataDir, nodeName, "files"))
+	if err != nil {
+		sugaredLogger.Panicf("Failed to resolve file store path: %s", err)
+	}
+	if err := os.MkdirAll(fileDir, 0700); err != nil {
+		sugaredLogger.Panicf("Failed to create file store: %s", err)
+	}
+
+	cb := newCommittedBatches(blockStore)
+	err = cb.load()
+	if err != nil {
+		sugaredLogger.Panicf("Failed to load ledger: %s", err)
 	}
 
 	app := &App{
@@ -470,8 +698,28 @@ func newNode(id NodeID, walDir string, tlsPaths TLSPaths, rotateLeader bool, dec
 		nodeCert: cert,
 		caCert:   caPool,
 		nodeKey:  key,
+		certDir:  filepath.Dir(tlsPaths.CaCertificate),
+
+		Store: NewVFSStore("file://" + filepath.ToSlash(fileDir) + "/"),
+
+		submissions: newSubmissionTracker(fastConfig.RequestAutoRemoveTimeout),
+	}
+	app.syncer = newSynchronizer(app)
 
-		Store: NewMemoryStore(),
+	// Resume at the last persisted decision
+	if last := cb.last(); last != nil {
+		if err := proto.Unmarshal(last.Metadata, app.latestMD); err != nil {
+			sugaredLogger.Panicf("Failed to parse ledger metadata: %s", err)
+		}
+		app.lastDecision = &types.Decision{
+			Proposal:   last.Proposal,
+			Signatures: last.Signatures,
+		}
+		app.lastRecord = lastRecord{
+			proposal:   last.Proposal,
+			signatures: last.Signatures,
+		}
+		sugaredLogger.Infof("Loaded ledger with latest sequence %d", app.latestMD.LatestSequence)
 	}
 
 	config := fastConfig
@@ -480,7 +728,7 @@ func newNode(id NodeID, walDir string, tlsPaths TLSPaths, rotateLeader bool, dec
 	//config.LeaderRotation = rotateLeader
 	//config.DecisionsPerLeader = decisionsPerLeader
 
-	writeAheadLog, walInitialEntries, err := wal.InitializeAndReadAll(app.logger, filepath.Join(walDir, nodeName), nil)
+	writeAheadLog, walInitialEntries, err := wal.InitializeAndReadAll(app.logger, filepath.Join(dataDir, nodeName, "wal"), nil)
 	if err != nil {
 		sugaredLogger.Panicf("Failed to initialize WAL: %s", err)
 	}
@@ -519,7 +767,7 @@ func newNode(id NodeID, walDir string, tlsPaths TLSPaths, rotateLeader bool, dec
 		c.ViewChangerTicker = app.viewChangeTime
 	}
 
-	node, err := StartNode(id, c, app, tlsPaths)
+	node, err := StartNode(id, c, app, cb, tlsPaths)
 
 	if err != nil {
 		sugaredLogger.Panicf("Failed to start Node: %s", err)
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+type testCA struct {
+	cert   *x509.Certificate
+	key    ed25519.PrivateKey
+	pool   *x509.CertPool
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: priv, pool: pool, serial: 1}
+}
+
+// issue returns a certificate with the given common name and organizational units signed by the CA
+func (ca *testCA) issue(t *testing.T, commonName string, units ...string) (*x509.Certificate, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: units},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, priv
+}
+
+// newTestApp returns an app without consensus and network for the given node,
+// persisting its ledger in a temporary directory
+func newTestApp(t *testing.T, ca *testCA, id NodeID, nodes ...NodeID) *App {
+	cert, key := ca.issue(t, fmt.Sprintf("node%v", id))
+
+	node := &Node{
+		id:            id,
+		peers:         make(map[NodeID]*Peer),
+		nodeExchanges: make(map[NodeID]NodeExchangeClient),
+		cb:            newCommittedBatches(openTestBlockStore(t, filepath.Join(t.TempDir(), "ledger"))),
+	}
+	for _, peer := range nodes {
+		node.peers[peer] = &Peer{PeerID: peer, Self: peer == id}
+	}
+
+	app := &App{
+		ID:          id,
+		Delivered:   make(chan *AppRecord, 100),
+		Node:        node,
+		latestMD:    &smartbftprotos.ViewMetadata{},
+		logger:      zap.NewNop().Sugar(),
+		submissions: newSubmissionTracker(time.Minute),
+		nodeCert:    cert,
+		nodeKey:     key,
+		caCert:      ca.pool,
+	}
+	node.app = app
+	app.syncer = newSynchronizer(app)
+	return app
+}
+
+// testDecision returns a proposal for the given sequence signed by the given consenters
+func testDecision(t *testing.T, sequence uint64, consenters []*App, requests ...[]byte) (types.Proposal, []types.Signature) {
+	metadata, err := proto.Marshal(&smartbftprotos.ViewMetadata{LatestSequence: sequence})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposal := types.Proposal{
+		Header:   blockHeader{Timestamp: time.Now().Unix()}.toBytes(),
+		Payload:  batch{Requests: requests}.toBytes(),
+		Metadata: metadata,
+	}
+
+	var signatures []types.Signature
+	for _, consenter := range consenters {
+		signatures = append(signatures, *consenter.SignProposal(proposal, []byte("prepares")))
+	}
+	return proposal, signatures
+}
+
+func TestVerifyQuorum(t *testing.T) {
+	ca := newTestCA(t)
+	nodes := []NodeID{1, 2, 3, 4}
+	var apps []*App
+	for _, id := range nodes {
+		apps = append(apps, newTestApp(t, ca, id, nodes...))
+	}
+	outsider := newTestApp(t, ca, 5, nodes...)
+	foreign := newTestApp(t, newTestCA(t), 3, nodes...)
+
+	proposal, signatures := testDecision(t, 1, apps, testReservedRequest(1))
+	other, otherSignatures := testDecision(t, 2, apps, testReservedRequest(2))
+	_, outsiderSignatures := testDecision(t, 1, []*App{outsider, foreign}, testReservedRequest(1))
+
+	// Signature of node 1 claiming to be node 3
+	impersonated := signatures[0]
+	impersonated.ID = 3
+
+	for _, tc := range []struct {
+		name       string
+		signatures []types.Signature
+		valid      bool
+	}{
+		{"all consenters", signatures, true},
+		{"quorum", signatures[1:], true},
+		{"below quorum", signatures[:2], false},
+		{"duplicate signer", []types.Signature{signatures[0], signatures[0], signatures[1]}, false},
+		{"non-consenter", append([]types.Signature{outsiderSignatures[0]}, signatures[:2]...), false},
+		{"foreign CA", append([]types.Signature{outsiderSignatures[1]}, signatures[:2]...), false},
+		{"impersonated consenter", []types.Signature{signatures[0], signatures[1], impersonated}, false},
+		{"other proposal", append([]types.Signature{otherSignatures[2]}, signatures[:2]...), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := apps[3].verifyQuorum(proposal, tc.signatures)
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified proposal without quorum")
+			}
+		})
+	}
+
+	if err := apps[3].verifyQuorum(other, otherSignatures); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyQuorumConsenterSet(t *testing.T) {
+	ca := newTestCA(t)
+	var consenters []*App
+	for id := NodeID(1); id <= 8; id++ {
+		consenters = append(consenters, newTestApp(t, ca, id, 1, 2, 3, 4))
+	}
+
+	// The peer view of the verifying node only contains 4 nodes, while 7 nodes were reconfigured
+	app := consenters[0]
+	reconfig := Request{
+		ClientID: reconfigClientID,
+		ID:       "reconfig-1",
+		Reconfig: Reconfig{InLatestDecision: true, CurrentNodes: []int64{1, 2, 3, 4, 5, 6, 7}},
+	}
+	commitTestRecord(t, app, time.Now().Unix(), &reconfig)
+
+	proposal, signatures := testDecision(t, 2, consenters, testReservedRequest(2))
+
+	for _, tc := range []struct {
+		name       string
+		signatures []types.Signature
+		valid      bool
+	}{
+		{"quorum of consenter set", signatures[:5], true},
+		{"quorum of peer view", signatures[:3], false},
+		{"below quorum of consenter set", signatures[:4], false},
+		// Node 8 holds a valid certificate, but is not a consenter
+		{"non-consenter", append(append([]types.Signature{}, signatures[:4]...), signatures[7]), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := app.verifyQuorum(proposal, tc.signatures)
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified proposal without quorum of the consenter set")
+			}
+		})
+	}
+}
+
+// signTestRequest signs the request with the given node certificate as done by App.Submit
+func signTestRequest(req Request, cert *x509.Certificate, key ed25519.PrivateKey) Request {
+	req.Certificate = cert.Raw
+	req.Signature = ed25519.Sign(key, req.signedBytes())
+	return req
+}
+
+func TestVerifyRequest(t *testing.T) {
+	ca := newTestCA(t)
+	app := newTestApp(t, ca, 2, 1, 2, 3, 4)
+	cert, key := ca.issue(t, "node1")
+	otherCert, otherKey := ca.issue(t, "node3")
+	foreignCert, foreignKey := newTestCA(t).issue(t, "node1")
+
+	request := Request{ClientID: "node-1", ID: "request-1", Type: SystemReserved}
+	reconfig := Reconfig{InLatestDecision: true, CurrentNodes: []int64{1, 2, 3, 4, 5}}
+
+	for _, tc := range []struct {
+		name    string
+		request func() Request
+		valid   bool
+	}{
+		{
+			name:    "signed by client node",
+			request: func() Request { return signTestRequest(request, cert, key) },
+			valid:   true,
+		},
+		{
+			name:    "unsigned",
+			request: func() Request { return request },
+		},
+		{
+			name:    "signed by other node",
+			request: func() Request { return signTestRequest(request, otherCert, otherKey) },
+		},
+		{
+			name:    "signed by foreign CA",
+			request: func() Request { return signTestRequest(request, foreignCert, foreignKey) },
+		},
+		{
+			name: "certificate of client node with other key",
+			request: func() Request {
+				signed := signTestRequest(request, otherCert, otherKey)
+				signed.Certificate = cert.Raw
+				return signed
+			},
+		},
+		{
+			name: "modified after signing",
+			request: func() Request {
+				signed := signTestRequest(request, cert, key)
+				signed.ID = "request-2"
+				return signed
+			},
+		},
+		{
+			name: "reconfig in client request",
+			request: func() Request {
+				withReconfig := request
+				withReconfig.Reconfig = reconfig
+				return signTestRequest(withReconfig, cert, key)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.request()
+			_, err := app.VerifyRequest(req.ToBytes())
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified invalid request")
+			}
+		})
+	}
+}
+
+// commitTestRecord commits the requests to the ledger of the app at the given ledger time
+func commitTestRecord(t *testing.T, a *App, timestamp int64, requests ...*Request) {
+	record := testStateRecord(timestamp, requests...)
+	record.Metadata = testRecord(t, a.Node.cb.latestMD.LatestSequence+1).Metadata
+	record.Proposal.Metadata = record.Metadata
+	a.Node.cb.add(record)
+	a.resolveSubmissions(record)
+}
+
+func TestVerifyReconfigSigner(t *testing.T) {
+	ca := newTestCA(t)
+	reconfig := Request{
+		ClientID: reconfigClientID,
+		ID:       "reconfig-2",
+		Reconfig: Reconfig{InLatestDecision: true, CurrentNodes: []int64{1, 2, 4, 5}},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		signer     string
+		consenters []int64 // of a committed reconfig, peer view if empty
+		valid      bool
+	}{
+		{"consenter", "node3", nil, true},
+		{"non-consenter", "node5", nil, false},
+		{"consenter of committed reconfig", "node5", []int64{1, 2, 4, 5}, true},
+		{"removed by committed reconfig", "node3", []int64{1, 2, 4, 5}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(t, ca, 1, 1, 2, 3, 4)
+			if tc.consenters != nil {
+				commitTestRecord(t, app, time.Now().Unix(), &Request{
+					ClientID: reconfigClientID,
+					ID:       "reconfig-1",
+					Reconfig: Reconfig{InLatestDecision: true, CurrentNodes: tc.consenters},
+				})
+			}
+
+			cert, key := ca.issue(t, tc.signer)
+			signed := signTestRequest(reconfig, cert, key)
+			err := app.verifyClientSignature(&signed)
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified reconfig of non-consenter")
+			}
+		})
+	}
+}
+
+func TestVerifyHeader(t *testing.T) {
+	ca := newTestCA(t)
+	now := time.Now().Unix()
+	tolerance := int64(proposalTimeTolerance / time.Second)
+
+	for _, tc := range []struct {
+		name       string
+		ledgerTime int64
+		header     []byte
+		valid      bool
+	}{
+		{"current time", now - 10, blockHeader{Timestamp: now}.toBytes(), true},
+		{"ledger time", now - 10, blockHeader{Timestamp: now - 10}.toBytes(), true},
+		{"below ledger time", now - 10, blockHeader{Timestamp: now - 11}.toBytes(), false},
+		{"ahead within tolerance", now - 10, blockHeader{Timestamp: now + tolerance - 5}.toBytes(), true},
+		{"ahead beyond tolerance", now - 10, blockHeader{Timestamp: now + tolerance + 5}.toBytes(), false},
+		{"behind within tolerance", now - 100, blockHeader{Timestamp: now - tolerance + 5}.toBytes(), true},
+		{"behind beyond tolerance", now - 100, blockHeader{Timestamp: now - tolerance - 5}.toBytes(), false},
+		{"missing header", now - 10, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(t, ca, 1, 1, 2, 3, 4)
+			commitTestRecord(t, app, tc.ledgerTime)
+
+			_, err := app.verifyHeader(types.Proposal{Header: tc.header})
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified invalid header")
+			}
+		})
+	}
+}
+
+func TestVerifyProposalLedgerTime(t *testing.T) {
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, "node2")
+	now := time.Now().Unix()
+
+	// The allowance is valid by the local clock, but expires before the proposal timestamp
+	window := [2]int64{now - 100, now + 10}
+
+	for _, tc := range []struct {
+		name      string
+		timestamp int64
+		applied   bool
+	}{
+		{"within window", now + 5, true},
+		{"expired at ledger time", now + 15, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(t, ca, 1, 1, 2, 3, 4)
+			commitTestRecord(t, app, now-50, testAddFile(t, 1), testAllow(t, 1, 2, 5, window))
+
+			// Conflicting requests are committed without effect, followers verify the proposal either way
+			announce := signTestRequest(*testAnnounce(t, 2, 1), cert, key)
+			proposal := types.Proposal{
+				Header:  blockHeader{Timestamp: tc.timestamp}.toBytes(),
+				Payload: batch{Requests: [][]byte{announce.ToBytes()}}.toBytes(),
+			}
+			if _, err := app.VerifyProposal(proposal); err != nil {
+				t.Fatal(err)
+			}
+
+			commitTestRecord(t, app, tc.timestamp, &announce)
+			reason, skipped := app.Node.cb.skipReason(announce.ID)
+			if tc.applied && skipped {
+				t.Fatal(reason)
+			}
+			if !tc.applied && !skipped {
+				t.Fatal("Applied announcement on expired allowance")
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Role of an API user, matching the parties running a node
+type Role string
+
+const (
+	RoleDataProvider Role = "provider"     // original manufacturer, owns fabrication data
+	RoleLogistics    Role = "logistics"    // data logistics, provides large file storage
+	RoleManufacturer Role = "manufacturer" // contracted manufacturer, fabricates parts
+)
+
+var allRoles = []Role{RoleDataProvider, RoleLogistics, RoleManufacturer}
+
+const tokenCookieName = "fabrico_token"
+
+// Principal is an authenticated API user
+type Principal struct {
+	Name  string
+	Roles []Role
+}
+
+func (p *Principal) hasRole(roles []Role) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadTokens reads API tokens from a JSON file mapping the hex encoded SHA-256 hash of a token
+// to its principal, so the file does not contain usable credentials
+func loadTokens(path string) (map[string]*Principal, error) {
+	tokens := make(map[string]*Principal)
+	if path == "" {
+		return tokens, nil
+	}
+
+	rawTokens, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rawTokens, &tokens); err != nil {
+		return nil, err
+	}
+
+	for hash, principal := range tokens {
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha256.Size {
+			return nil, errors.New("token file keys must be hex encoded SHA-256 hashes")
+		}
+		for _, role := range principal.Roles {
+			if !(&Principal{Roles: allRoles}).hasRole([]Role{role}) {
+				return nil, errors.New("unknown role " + string(role))
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// loadAPITLSConfig returns the HTTPS configuration, requesting optional client certificates issued by our CA.
+// Without a dedicated certificate the node certificate is used, which is not accepted by all browsers.
+func loadAPITLSConfig(paths TLSPaths, certPath, keyPath string) (*tls.Config, error) {
+	if certPath == "" {
+		certPath, keyPath = paths.NodeCertificate, paths.NodeKey
+	}
+	serverCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	caPEM, err := os.ReadFile(paths.CaCertificate)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no CA certificate found in path")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// tokenFromRequest returns the bearer token or login cookie of the request
+func tokenFromRequest(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if cookie, err := req.Cookie(tokenCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (a *APIServer) principalForToken(token string) (*Principal, bool) {
+	if token == "" {
+		return nil, false
+	}
+	hash := sha256.Sum256([]byte(token))
+	principal, ok := a.Tokens[hex.EncodeToString(hash[:])]
+	return principal, ok
+}
+
+// authenticate returns the principal of a verified client certificate, whose organizational units
+// name its roles, or of a known token
+func (a *APIServer) authenticate(req *http.Request) (*Principal, bool) {
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		return principalForCertificate(req.TLS.VerifiedChains[0][0]), true
+	}
+
+	return a.principalForToken(tokenFromRequest(req))
+}
+
+// principalForCertificate returns the principal of a verified client certificate,
+// node certificates carry no organizational units and therefore no roles
+func principalForCertificate(cert *x509.Certificate) *Principal {
+	principal := &Principal{Name: cert.Subject.CommonName}
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		principal.Roles = append(principal.Roles, Role(unit))
+	}
+	return principal
+}
+
+// authorize wraps the handler, restricting it to authenticated principals with one of the given roles
+func (a *APIServer) authorize(handler http.HandlerFunc, roles ...Role) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		principal, ok := a.authenticate(req)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !principal.hasRole(roles) {
+			a.Node.app.logger.Warnf("Denied %v access to %v", principal.Name, req.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// Login checks the form value token and stores it in a session cookie for the web interface
+func (a *APIServer) Login(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := req.FormValue("token")
+	principal, ok := a.principalForToken(token)
+	if !ok {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookieName,
+		Value:    token,
+		Path:     "/api/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	a.encodeJSON(w, principal)
+}
+
+// Logout removes the session cookie
+func (a *APIServer) Logout(w http.ResponseWriter, _ *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookieName,
+		Path:     "/api/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
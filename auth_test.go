@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// verifiedConnection returns the TLS state of a connection with the given verified client certificate
+func verifiedConnection(cert *x509.Certificate) *tls.ConnectionState {
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
+
+func TestAuthorize(t *testing.T) {
+	ca := newTestCA(t)
+	app := newTestApp(t, ca, 1, 1)
+
+	tokenHash := func(token string) string {
+		hash := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(hash[:])
+	}
+	api := &APIServer{
+		Node: app.Node,
+		Tokens: map[string]*Principal{
+			tokenHash("provider-token"):     {Name: "alice", Roles: []Role{RoleDataProvider}},
+			tokenHash("manufacturer-token"): {Name: "bob", Roles: []Role{RoleManufacturer}},
+		},
+	}
+	handler := api.authorize(func(w http.ResponseWriter, req *http.Request) {}, RoleDataProvider, RoleLogistics)
+
+	providerCert, _ := ca.issue(t, "carol", string(RoleDataProvider))
+	manufacturerCert, _ := ca.issue(t, "dave", string(RoleManufacturer))
+	nodeCert, _ := ca.issue(t, "node2")
+
+	for _, tc := range []struct {
+		name    string
+		prepare func(req *http.Request)
+		status  int
+	}{
+		{
+			name:    "anonymous",
+			prepare: func(req *http.Request) {},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "unknown token",
+			prepare: func(req *http.Request) { req.Header.Set("Authorization", "Bearer unknown") },
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "token with role",
+			prepare: func(req *http.Request) { req.Header.Set("Authorization", "Bearer provider-token") },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "token without role",
+			prepare: func(req *http.Request) { req.Header.Set("Authorization", "Bearer manufacturer-token") },
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "cookie with role",
+			prepare: func(req *http.Request) { req.AddCookie(&http.Cookie{Name: tokenCookieName, Value: "provider-token"}) },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "certificate with role",
+			prepare: func(req *http.Request) { req.TLS = verifiedConnection(providerCert) },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "certificate without role",
+			prepare: func(req *http.Request) { req.TLS = verifiedConnection(manufacturerCert) },
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "node certificate",
+			prepare: func(req *http.Request) { req.TLS = verifiedConnection(nodeCert) },
+			status:  http.StatusForbidden,
+		},
+		{
+			// The verified certificate takes precedence over tokens
+			name: "certificate without role and token with role",
+			prepare: func(req *http.Request) {
+				req.TLS = verifiedConnection(manufacturerCert)
+				req.Header.Set("Authorization", "Bearer provider-token")
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "unverified certificate",
+			prepare: func(req *http.Request) {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{providerCert}}
+			},
+			status: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			tc.prepare(req)
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+			if recorder.Code != tc.status {
+				t.Fatalf("Status %d, expected %d", recorder.Code, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+)
+
+// Every block is stored as an entry of the following form:
+// 4 bytes big endian length of data, 4 bytes big endian CRC32 (IEEE) of data, ASN.1 encoded storedBlock
+const blockEntryHeaderSize = 8
+
+var ErrCorruptedBlockStore = errors.New("corrupted block store")
+
+// storedBlock is the persisted form of a decided proposal
+type storedBlock struct {
+	Version    int
+	PrevHash   []byte
+	Proposal   types.Proposal
+	Signatures []storedSignature
+}
+
+// storedSignature mirrors types.Signature, ASN1 cannot serialize uint
+type storedSignature struct {
+	ID    int64
+	Value []byte
+	Msg   []byte
+}
+
+// FileBlockStore is an append-only, crash-safe store for committed blocks.
+// Every append is synced to disk before returning, incomplete trailing entries
+// (e.g. caused by a crash during write) are truncated when opening the store.
+type FileBlockStore struct {
+	sync.Mutex
+	file *os.File
+}
+
+func OpenFileBlockStore(path string) (*FileBlockStore, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileBlockStore{file: file}, nil
+}
+
+// Append persists the given record
+func (s *FileBlockStore) Append(record *AppRecord) error {
+	block := storedBlock{
+		Version:  1,
+		PrevHash: record.PrevHash,
+		Proposal: record.Proposal,
+	}
+	for _, sig := range record.Signatures {
+		block.Signatures = append(block.Signatures, storedSignature{
+			ID:    int64(sig.ID),
+			Value: sig.Value,
+			Msg:   sig.Msg,
+		})
+	}
+
+	data, err := asn1.Marshal(block)
+	if err != nil {
+		return err
+	}
+
+	entry := make([]byte, blockEntryHeaderSize, blockEntryHeaderSize+len(data))
+	binary.BigEndian.PutUint32(entry[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(entry[4:8], crc32.ChecksumIEEE(data))
+	entry = append(entry, data...)
+
+	s.Lock()
+	defer s.Unlock()
+
+	_, err = s.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.file.Write(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// ReadAll returns all persisted records in order of appending
+func (s *FileBlockStore) ReadAll() ([]*AppRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	_, err := s.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(s.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*AppRecord
+	var offset int
+
+	for offset < len(content) {
+		entry := content[offset:]
+
+		if len(entry) < blockEntryHeaderSize {
+			// Incomplete header at end of file
+			break
+		}
+
+		length := int(binary.BigEndian.Uint32(entry[:4]))
+		checksum := binary.BigEndian.Uint32(entry[4:8])
+
+		if len(entry)-blockEntryHeaderSize < length {
+			// Incomplete data at end of file
+			break
+		}
+
+		data := entry[blockEntryHeaderSize : blockEntryHeaderSize+length]
+		if crc32.ChecksumIEEE(data) != checksum {
+			if blockEntryHeaderSize+length == len(entry) {
+				// Torn write of last entry
+				break
+			}
+			return nil, ErrCorruptedBlockStore
+		}
+
+		block := storedBlock{}
+		rest, err := asn1.Unmarshal(data, &block)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, errors.New("unexpected trailing data")
+		}
+		if block.Version != 1 {
+			return nil, errors.New("unexpected stored block version")
+		}
+
+		record := &AppRecord{
+			Batch:    batchFromBytes(block.Proposal.Payload),
+			Metadata: block.Proposal.Metadata,
+			PrevHash: block.PrevHash,
+			Proposal: block.Proposal,
+		}
+		for _, sig := range block.Signatures {
+			record.Signatures = append(record.Signatures, types.Signature{
+				ID:    uint64(sig.ID),
+				Value: sig.Value,
+				Msg:   sig.Msg,
+			})
+		}
+
+		records = append(records, record)
+		offset += blockEntryHeaderSize + length
+	}
+
+	if offset < len(content) {
+		// Remove incomplete trailing entry, so following appends stay readable
+		err = s.file.Truncate(int64(offset))
+		if err != nil {
+			return nil, err
+		}
+		err = s.file.Sync()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+func (s *FileBlockStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"google.golang.org/protobuf/proto"
+)
+
+// testRecord returns a record for the given sequence carrying the given requests
+func testRecord(t *testing.T, sequence uint64, requests ...[]byte) *AppRecord {
+	metadata, err := proto.Marshal(&smartbftprotos.ViewMetadata{LatestSequence: sequence})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := batch{Requests: requests}
+	return &AppRecord{
+		Batch:    &b,
+		Metadata: metadata,
+		Proposal: types.Proposal{
+			Header:   blockHeader{Timestamp: int64(sequence)}.toBytes(),
+			Payload:  b.toBytes(),
+			Metadata: metadata,
+		},
+		Signatures: []types.Signature{{ID: 1, Value: []byte("value"), Msg: []byte("msg")}},
+	}
+}
+
+func openTestBlockStore(t *testing.T, path string) *FileBlockStore {
+	store, err := OpenFileBlockStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestFileBlockStoreReadAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+	store := openTestBlockStore(t, path)
+
+	records := []*AppRecord{testRecord(t, 1, []byte("request 1")), testRecord(t, 2), testRecord(t, 3, []byte("request 2"), []byte("request 3"))}
+	for _, record := range records {
+		if err := store.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Close()
+
+	read, err := openTestBlockStore(t, path).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(records) {
+		t.Fatalf("Read %d records, expected %d", len(read), len(records))
+	}
+	for i := range records {
+		if !bytes.Equal(read[i].Hash(), records[i].Hash()) {
+			t.Fatalf("Record %d changed on disk", i)
+		}
+		if len(read[i].Signatures) != 1 || !bytes.Equal(read[i].Signatures[0].Value, []byte("value")) {
+			t.Fatalf("Signatures of record %d not persisted", i)
+		}
+	}
+}
+
+func TestFileBlockStoreTornWrite(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		corrupt func(content []byte, lastEntry int) []byte
+		records int
+		err     error
+	}{
+		{
+			name:    "partial header",
+			corrupt: func(content []byte, lastEntry int) []byte { return content[:lastEntry+blockEntryHeaderSize/2] },
+			records: 1,
+		},
+		{
+			name:    "partial data",
+			corrupt: func(content []byte, lastEntry int) []byte { return content[:len(content)-1] },
+			records: 1,
+		},
+		{
+			name: "checksum mismatch in last entry",
+			corrupt: func(content []byte, lastEntry int) []byte {
+				content[len(content)-1] ^= 1
+				return content
+			},
+			records: 1,
+		},
+		{
+			name: "checksum mismatch before last entry",
+			corrupt: func(content []byte, lastEntry int) []byte {
+				content[blockEntryHeaderSize] ^= 1
+				return content
+			},
+			err: ErrCorruptedBlockStore,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ledger")
+			store := openTestBlockStore(t, path)
+			if err := store.Append(testRecord(t, 1, []byte("request 1"))); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lastEntry := int(info.Size())
+			if err := store.Append(testRecord(t, 2, []byte("request 2"))); err != nil {
+				t.Fatal(err)
+			}
+			store.Close()
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tc.corrupt(content, lastEntry), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			store = openTestBlockStore(t, path)
+			records, err := store.ReadAll()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if len(records) != tc.records {
+				t.Fatalf("Read %d records, expected %d", len(records), tc.records)
+			}
+
+			// The torn entry is truncated, so following appends stay readable
+			info, err = os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int(info.Size()) != lastEntry {
+				t.Fatalf("Store has size %d after truncation, expected %d", info.Size(), lastEntry)
+			}
+			if err := store.Append(testRecord(t, 2, []byte("request 2"))); err != nil {
+				t.Fatal(err)
+			}
+			records, err = store.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tc.records+1 {
+				t.Fatalf("Read %d records after append, expected %d", len(records), tc.records+1)
+			}
+		})
+	}
+}
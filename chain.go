@@ -1,35 +1,160 @@
 package main
 
 import (
+	"bytes"
 	"encoding/asn1"
-	"encoding/binary"
+	"errors"
+	"fmt"
 	"sync"
 
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
 	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"golang.org/x/crypto/sha3"
 	"google.golang.org/protobuf/proto"
 )
 
-type AllowCount struct {
-	NodeID         NodeID
-	RemainingCount int
-}
-
-func newCommittedBatches() *committedBatches {
+func newCommittedBatches(store *FileBlockStore) *committedBatches {
 	return &committedBatches{
-		knownFiles:   make(map[FabricationDataHash]NodeID),
-		allowedNodes: make(map[FabricationDataHash][]*AllowCount),
+		store:     store,
+		state:     newLedgerState(),
+		skipped:   make(map[string]string),
+		txIndex:   make(map[string][]txLocation),
+		fileIndex: make(map[FabricationDataHash][]txLocation),
 	}
 }
 
+// txLocation addresses a request within the committed records
+type txLocation struct {
+	record  int
+	request int
+}
+
 type committedBatches struct {
 	lock     sync.RWMutex
 	latestMD smartbftprotos.ViewMetadata
 	records  []*AppRecord
+	store    *FileBlockStore
+
+	// aggregated state
+	state *ledgerState
+
+	// reasons for committed requests which were not applied to the state, by request ID
+	skipped map[string]string
+
+	// committed requests by request ID and by referenced file, in order of commitment
+	txIndex   map[string][]txLocation
+	fileIndex map[FabricationDataHash][]txLocation
+
+	// consenters of the latest committed reconfig, nil before the first
+	consenters []uint64
+}
+
+// load reads all persisted records from the block store, verifies the hash chain and rebuilds aggregations
+func (cb *committedBatches) load() error {
+	records, err := cb.store.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	for i, record := range records {
+		if !bytes.Equal(record.PrevHash, cb.tipHashLocked()) {
+			return fmt.Errorf("hash chain broken at stored block %d", i)
+		}
+		if len(record.Proposal.Header) == 0 {
+			return fmt.Errorf("stored block %d has no header", i)
+		}
+
+		if err := proto.Unmarshal(record.Metadata, &cb.latestMD); err != nil {
+			return err
+		}
+
+		// Skipping requests of another encoding would silently diverge the state from the ledger history
+		if err := checkEncoding(record); err != nil {
+			return fmt.Errorf("stored block %d: %w", i, err)
+		}
+
+		cb.records = append(cb.records, record)
+		cb.indexLocked(len(cb.records) - 1)
+		cb.applyLocked(record)
+	}
+
+	return nil
+}
+
+// checkEncoding ensures that all requests of the record can be decoded by this version.
+// Proposals with undecodable requests are rejected by consenters, see App.VerifyProposal,
+// so these can only be written by an incompatible version.
+func checkEncoding(record *AppRecord) error {
+	for i, reqBytes := range record.Batch.Requests {
+		request, err := parseRequest(reqBytes)
+		if err != nil {
+			return fmt.Errorf("request %d: %w: %v", i, ErrUnsupportedEncoding, err)
+		}
+		if request.Type == SystemReserved {
+			continue
+		}
+		if _, err := request.decodePayload(); err != nil {
+			if errors.Is(err, ErrUnsupportedEncoding) {
+				return fmt.Errorf("request %s: %w", request.ID, err)
+			}
+			return fmt.Errorf("request %s: %w: %v", request.ID, ErrUnsupportedEncoding, err)
+		}
+	}
+	return nil
+}
+
+// indexLocked adds the requests of the record at the given position to the lookup indexes
+func (cb *committedBatches) indexLocked(position int) {
+	for i, reqBytes := range cb.records[position].Batch.Requests {
+		request, err := parseRequest(reqBytes)
+		if err != nil {
+			continue
+		}
+		if request.isReconfig() {
+			cb.consenters = nodesToUint(request.Reconfig.CurrentNodes)
+		}
+		location := txLocation{record: position, request: i}
+		cb.txIndex[request.ID] = append(cb.txIndex[request.ID], location)
+		if hash, ok := request.fileHash(); ok {
+			cb.fileIndex[hash] = append(cb.fileIndex[hash], location)
+		}
+	}
+}
+
+// consenterSet returns the consenters of the latest committed reconfig, which decide the next block
+func (cb *committedBatches) consenterSet() ([]uint64, bool) {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+	return cb.consenters, cb.consenters != nil
+}
+
+// tipHash returns the hash of the latest committed record, which is the expected
+// prevHash of the next record. The chain starts with an empty prevHash.
+func (cb *committedBatches) tipHash() []byte {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+	return cb.tipHashLocked()
+}
+
+func (cb *committedBatches) tipHashLocked() []byte {
+	if len(cb.records) == 0 {
+		return nil
+	}
+	return cb.records[len(cb.records)-1].Hash()
+}
 
-	// aggregated fields
-	aggregationLock sync.Mutex
-	knownFiles      map[FabricationDataHash]NodeID // mapping from file hash to originating node id
-	allowedNodes    map[FabricationDataHash][]*AllowCount
+// last returns the latest committed record or nil if none exist
+func (cb *committedBatches) last() *AppRecord {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	if len(cb.records) == 0 {
+		return nil
+	}
+	return cb.records[len(cb.records)-1]
 }
 
 func (cb *committedBatches) add(record *AppRecord) {
@@ -49,88 +174,40 @@ func (cb *committedBatches) add(record *AppRecord) {
 		cb.lock.Unlock()
 		return
 	}
+
+	// Chain record to its predecessor
+	record.PrevHash = cb.tipHashLocked()
+
+	// Persist before acknowledging the record in memory
+	if err := cb.store.Append(record); err != nil {
+		cb.lock.Unlock()
+		panic(err)
+	}
+
 	cb.latestMD = *md
 	cb.records = append(cb.records, record)
+	cb.indexLocked(len(cb.records) - 1)
+
+	// Process aggregations in order of delivery, before the next proposal is verified
+	cb.applyLocked(record)
 
 	cb.lock.Unlock()
-	// Process aggregations
-	// *Has to be done asynchronously to prevent consensus failures!*
-	// TODO Client signatures (prevent spoofing)
-
-	go func() {
-		for _, reqBytes := range record.Batch.Requests {
-			request := requestFromBytes(reqBytes)
-
-			switch RequestType(request.Type) {
-			case SystemReserved:
-				// No aggregations from system messages
-				continue
-
-			case AddFile:
-				//Payload is 64 bits hash, 64 bit uint originating node
-				var address FabricationDataHash
-				copy(address[:], request.Payload[:64])
-
-				originatingNode := binary.LittleEndian.Uint64(request.Payload[64:])
-
-				cb.aggregationLock.Lock()
-				cb.knownFiles[address] = NodeID(originatingNode)
-				cb.aggregationLock.Unlock()
-
-				// TODO add more verifications for file ownership?
-				// TODO difference between originating and distributing nodes
-
-			case AllowFabrication:
-				//64 bits Hash, 64 Bits Uint64 Allowed Node, Count Sets Maximum Parts
-
-				var address FabricationDataHash
-				copy(address[:], request.Payload[:64])
-
-				allowedNode := binary.LittleEndian.Uint64(request.Payload[64:])
-
-				allowCounts, ok := cb.allowedNodes[address]
-				if !ok {
-					cb.aggregationLock.Lock()
-					cb.allowedNodes[address] = []*AllowCount{{
-						NodeID:         NodeID(allowedNode),
-						RemainingCount: request.Count,
-					}}
-					cb.aggregationLock.Unlock()
-					continue
-				}
-
-				setCount := false
-				// Check if node has allow count
-				for _, count := range allowCounts {
-					if count.NodeID == NodeID(allowedNode) {
-						count.RemainingCount = count.RemainingCount + request.Count
-						setCount = true
-						break
-					}
-				}
-
-				// Node needs new allow count
-				if !setCount {
-					cb.aggregationLock.Lock()
-					cb.allowedNodes[address] = append(cb.allowedNodes[address], &AllowCount{
-						NodeID:         NodeID(allowedNode),
-						RemainingCount: request.Count,
-					})
-					cb.aggregationLock.Unlock()
-				}
-
-			case AnnounceFabrication:
-				//64bits Hash, Count Number of Parts intended to produce
-				panic("not yet implemented")
-
-			case CancelFabrication:
-				//64bits Hash, Count Number of Parts not produced
-				panic("not yet implemented")
+}
 
-			}
-		}
-	}()
+// applyLocked applies the record to the state, remembering requests which were skipped
+func (cb *committedBatches) applyLocked(record *AppRecord) {
+	for requestID, err := range cb.state.apply(record) {
+		cb.skipped[requestID] = err.Error()
+	}
+}
+
+// skipReason returns why a committed request was not applied to the state
+func (cb *committedBatches) skipReason(requestID string) (string, bool) {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
 
+	reason, ok := cb.skipped[requestID]
+	return reason, ok
 }
 
 func (cb *committedBatches) readAll(from smartbftprotos.ViewMetadata) []*AppRecord {
@@ -148,8 +225,11 @@ func (cb *committedBatches) readAll(from smartbftprotos.ViewMetadata) []*AppReco
 			continue
 		}
 		res = append(res, &AppRecord{
-			Metadata: entry.Metadata,
-			Batch:    entry.Batch,
+			Metadata:   entry.Metadata,
+			Batch:      entry.Batch,
+			PrevHash:   entry.PrevHash,
+			Proposal:   entry.Proposal,
+			Signatures: entry.Signatures,
 		})
 	}
 	return res
@@ -160,18 +240,17 @@ type Request struct {
 	ClientID string // Currently always originating NodeID
 	ID       string // Request UUID
 	Type     RequestType
-	Payload  []byte // Message type specific payload data
-	Count    int    // Message type specific count value
+	Payload  []byte // Protobuf encoded TransactionPayload, see node_messages.proto
 	Reconfig Reconfig
-}
 
-// AddFile Payload: 64 bits Hash, 64 Bits Uint64 Originating Node
-// AllowFabrication Payload: 64 bits Hash, 64 Bits Uint64 Allowed Node, Count Sets Maximum Parts
+	// Ed25519 signature over all other fields by the node certificate
+	Signature   []byte
+	Certificate []byte
+}
 
-// TODO implement Announcement / Cancellation!
-// Announcing and Canceling protects against Network / Power Glitches to prevent production of excess parts
-// AnnounceFabrication Payload: 64bits Hash, Count Number of Parts intended to produce
-// CancelFabrication Payload: 64bits Hash, Count Number of Parts not produced - must correspond to earlier announce request
+// Each RequestType except SystemReserved carries the TransactionPayload field of the same name.
+// RevokeFabrication and AdjustFabrication are issued by the originating node to withdraw or change
+// an allowance, TransferFabrication by an allowed node to pass part of its allowance to a subcontractor.
 
 type RequestType int
 
@@ -181,10 +260,20 @@ const (
 	AllowFabrication
 	AnnounceFabrication
 	CancelFabrication
+	FabricationCompleted
+	RevokeFabrication
+	AdjustFabrication
+	TransferFabrication
 )
 
+var requestTypeNames = [...]string{"SystemReserved", "AddFile", "AllowFabrication", "AnnounceFabrication", "CancelFabrication", "FabricationCompleted", "RevokeFabrication", "AdjustFabrication", "TransferFabrication"}
+
+// String returns the name of the request type, types of other versions are printed by number
 func (t RequestType) String() string {
-	return [...]string{"SystemReserved", "AddFile", "AllowFabrication", "AnnounceFabrication", "CancelFabrication"}[t]
+	if t < 0 || int(t) >= len(requestTypeNames) {
+		return fmt.Sprintf("RequestType(%d)", t)
+	}
+	return requestTypeNames[t]
 }
 
 // ToBytes returns a byte array representation of the request
@@ -196,16 +285,31 @@ func (txn Request) ToBytes() []byte {
 	return rawTxn
 }
 
+// signedBytes returns the byte array representation covered by the request signature
+func (txn Request) signedBytes() []byte {
+	txn.Signature = nil
+	return txn.ToBytes()
+}
+
 func requestFromBytes(req []byte) *Request {
+	r, err := parseRequest(req)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseRequest is used for requests which have not been verified yet
+func parseRequest(req []byte) (*Request, error) {
 	var r Request
 	rest, err := asn1.Unmarshal(req, &r)
-	if len(rest) > 0 {
-		panic("unexpected trailing data")
-	}
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return &r
+	if len(rest) > 0 {
+		return nil, errors.New("unexpected trailing data")
+	}
+	return &r, nil
 }
 
 type batch struct {
@@ -226,8 +330,74 @@ func batchFromBytes(rawBlock []byte) *batch {
 	return &block
 }
 
+// blockHeader is assigned to the proposal header by the leader and verified by all consenters
+type blockHeader struct {
+	Timestamp int64 // Unix time in seconds, ledger time for evaluating license validity
+}
+
+func (h blockHeader) toBytes() []byte {
+	rawHeader, err := asn1.Marshal(h)
+	if err != nil {
+		panic(err)
+	}
+	return rawHeader
+}
+
+func headerFromBytes(rawHeader []byte) (*blockHeader, error) {
+	var h blockHeader
+	rest, err := asn1.Unmarshal(rawHeader, &h)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("unexpected trailing data")
+	}
+	return &h, nil
+}
+
 // AppRecord represents a committed batch and metadata
 type AppRecord struct {
 	Batch    *batch
 	Metadata []byte
+	PrevHash []byte // Hash of the preceding record, empty for the first record
+
+	// Decided proposal and consenter signatures as delivered by consensus
+	Proposal   types.Proposal
+	Signatures []types.Signature
+}
+
+// Hash returns the SHA3-256 hash of the record, covering the hash of its predecessor.
+// Signatures are not included, since nodes may collect different quorums for the same decision.
+// Committed records always carry a header, see App.verifyHeader.
+func (r *AppRecord) Hash() []byte {
+	if len(r.Proposal.Header) == 0 {
+		panic("record without header")
+	}
+
+	rawRecord, err := asn1.Marshal(struct {
+		PrevHash []byte
+		Metadata []byte
+		Requests [][]byte
+		Header   []byte
+	}{
+		PrevHash: r.PrevHash,
+		Metadata: r.Metadata,
+		Requests: r.Batch.Requests,
+		Header:   r.Proposal.Header,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	hash := sha3.Sum256(rawRecord)
+	return hash[:]
+}
+
+// Timestamp returns the ledger time assigned to the record, 0 if the record has no valid header
+func (r *AppRecord) Timestamp() int64 {
+	header, err := headerFromBytes(r.Proposal.Header)
+	if err != nil {
+		return 0
+	}
+	return header.Timestamp
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// testReservedRequest returns an encoded request without payload
+func testReservedRequest(id int) []byte {
+	return Request{ClientID: "node-1", ID: fmt.Sprintf("request-%d", id), Type: SystemReserved}.ToBytes()
+}
+
+func TestCommittedBatchesLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tamper func(records []*AppRecord)
+		err    string
+	}{
+		{
+			name:   "intact chain",
+			tamper: func(records []*AppRecord) {},
+		},
+		{
+			name: "first record with parent",
+			tamper: func(records []*AppRecord) {
+				records[0].PrevHash = bytes.Repeat([]byte{1}, 32)
+			},
+			err: "hash chain broken at stored block 0",
+		},
+		{
+			name: "modified request",
+			tamper: func(records []*AppRecord) {
+				records[1].Batch.Requests[0] = testReservedRequest(100)
+			},
+			err: "hash chain broken at stored block 2",
+		},
+		{
+			name: "removed record",
+			tamper: func(records []*AppRecord) {
+				records[1] = records[2]
+			},
+			err: "hash chain broken at stored block 1",
+		},
+		{
+			name: "undecodable request",
+			tamper: func(records []*AppRecord) {
+				records[2].Batch.Requests[0] = []byte("legacy request")
+			},
+			err: "stored block 2: request 0: unsupported request encoding",
+		},
+		{
+			name: "record without header",
+			tamper: func(records []*AppRecord) {
+				records[2].Proposal.Header = nil
+			},
+			err: "stored block 2 has no header",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cb := newCommittedBatches(openTestBlockStore(t, filepath.Join(dir, "ledger")))
+			for i := 1; i <= 3; i++ {
+				cb.add(testRecord(t, uint64(i), testReservedRequest(i)))
+			}
+
+			records, err := cb.store.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.tamper(records)
+
+			store := openTestBlockStore(t, filepath.Join(dir, "tampered"))
+			for _, record := range records {
+				// The batch is persisted as part of the proposal
+				record.Proposal.Payload = record.Batch.toBytes()
+				if err := store.Append(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			loaded := newCommittedBatches(store)
+			err = loaded.load()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(loaded.tipHash(), cb.tipHash()) {
+					t.Fatal("Loaded chain tip does not match")
+				}
+				if loaded.latestMD.LatestSequence != 3 {
+					t.Fatalf("Loaded sequence %d, expected 3", loaded.latestMD.LatestSequence)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCommittedBatchesLoadEncoding(t *testing.T) {
+	unknownVersion, err := proto.Marshal(&TransactionPayload{
+		Version: payloadVersion + 1,
+		Payload: &TransactionPayload_AddFile{AddFile: &AddFilePayload{FileHash: testFile[:], OriginNode: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		request []byte
+	}{
+		{"legacy request", []byte("legacy request")},
+		// Raw file hash followed by the request count, as written before payloads were versioned
+		{"raw payload", Request{ClientID: "node-1", ID: "request-2", Type: AllowFabrication, Payload: append(testFile[:], 0, 0, 0, 5)}.ToBytes()},
+		{"unknown payload version", Request{ClientID: "node-1", ID: "request-2", Type: AddFile, Payload: unknownVersion}.ToBytes()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cb := newCommittedBatches(openTestBlockStore(t, filepath.Join(t.TempDir(), "ledger")))
+			cb.add(testRecord(t, 1, testReservedRequest(1)))
+			cb.add(testRecord(t, 2, tc.request))
+
+			err := newCommittedBatches(cb.store).load()
+			if !errors.Is(err, ErrUnsupportedEncoding) {
+				t.Fatalf("Expected %v, got %v", ErrUnsupportedEncoding, err)
+			}
+		})
+	}
+}
+
+func TestRequestTypeString(t *testing.T) {
+	for _, tc := range []struct {
+		requestType RequestType
+		name        string
+	}{
+		{SystemReserved, "SystemReserved"},
+		{TransferFabrication, "TransferFabrication"},
+		{TransferFabrication + 1, fmt.Sprintf("RequestType(%d)", TransferFabrication+1)},
+		{-1, "RequestType(-1)"},
+	} {
+		if name := tc.requestType.String(); name != tc.name {
+			t.Errorf("Request type %d named %q, expected %q", int(tc.requestType), name, tc.name)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// clientAPI implements the client facing ClientAPI gRPC service
+type clientAPI struct {
+	node   *Node
+	events *eventBroker
+	UnimplementedClientAPIServer
+}
+
+// clientAPIRoles restricts each method to the roles using it, matching the HTTP API.
+// Methods not listed are denied.
+var clientAPIRoles = map[string][]Role{
+	"/fabrico.ClientAPI/SubmitFile":       {RoleDataProvider},
+	"/fabrico.ClientAPI/GrantFabrication": {RoleDataProvider},
+	"/fabrico.ClientAPI/QueryAvailable":   {RoleManufacturer},
+	"/fabrico.ClientAPI/GetBlock":         allRoles,
+	"/fabrico.ClientAPI/WatchEvents":      allRoles,
+}
+
+// ServeClientAPI serves the ClientAPI on the given endpoint, requiring client certificates issued by our CA
+// which name the roles of the user as organizational units
+func ServeClientAPI(node *Node, events *eventBroker, paths TLSPaths, endpoint string) error {
+	creds, err := loadClientAPICredentials(paths)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+
+	api := &clientAPI{node: node, events: events}
+	grpcServer := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.UnaryInterceptor(api.authorizeUnary),
+		grpc.StreamInterceptor(api.authorizeStream),
+	)
+	RegisterClientAPIServer(grpcServer, api)
+	return grpcServer.Serve(listener)
+}
+
+// loadClientAPICredentials returns the transport credentials of the ClientAPI.
+// Unlike the node exchange, user certificates issued by our CA are accepted.
+func loadClientAPICredentials(paths TLSPaths) (credentials.TransportCredentials, error) {
+	config, err := loadAPITLSConfig(paths, "", "")
+	if err != nil {
+		return nil, err
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return credentials.NewTLS(config), nil
+}
+
+// authorize checks that the client certificate of the caller names one of the roles allowed to call the method
+func (c *clientAPI) authorize(ctx context.Context, method string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	principal := principalForCertificate(tlsInfo.State.VerifiedChains[0][0])
+	if !principal.hasRole(clientAPIRoles[method]) {
+		c.node.app.logger.Warnf("Denied %v access to %v", principal.Name, method)
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+	return nil
+}
+
+func (c *clientAPI) authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := c.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (c *clientAPI) authorizeStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := c.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// submitStatus maps request submission errors to gRPC status errors
+func submitStatus(err error) error {
+	if errors.Is(err, ErrCommitTimeout) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func (c *clientAPI) SubmitFile(stream ClientAPI_SubmitFileServer) error {
+	metadata, content, err := receiveSubmitFile(stream)
+	if err != nil {
+		return err
+	}
+
+	hash, key, err := c.node.app.storeEncrypted(content)
+	if content.err != nil {
+		return content.err
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	nodes := make([]NodeID, 0, len(metadata.AllowedNodes))
+	for _, node := range metadata.AllowedNodes {
+		nodes = append(nodes, NodeID(node))
+	}
+
+	requestIDs, err := c.node.app.submitFile(hash, key, nodes, metadata.Count, metadata.ValidFrom, metadata.ValidUntil)
+	if err != nil {
+		return submitStatus(err)
+	}
+
+	return stream.SendAndClose(&SubmitFileResponse{
+		FileHash:   hash[:],
+		RequestIds: requestIDs,
+	})
+}
+
+// receiveSubmitFile reads the metadata of a SubmitFile stream and returns the reader of the following content,
+// which must not be empty
+func receiveSubmitFile(stream ClientAPI_SubmitFileServer) (*SubmitFileMetadata, *submitFileReader, error) {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil, nil, status.Error(codes.InvalidArgument, "missing metadata")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := first.GetMetadata()
+	if metadata == nil {
+		return nil, nil, status.Error(codes.InvalidArgument, "first message must carry the metadata")
+	}
+
+	content := &submitFileReader{stream: stream}
+	if err := content.fill(); err == io.EOF {
+		return nil, nil, status.Error(codes.InvalidArgument, "empty content")
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return metadata, content, nil
+}
+
+// submitFileReader reads the content chunks of a SubmitFile stream
+type submitFileReader struct {
+	stream ClientAPI_SubmitFileServer
+	buf    []byte
+	err    error // receiving failed or the client violated the protocol
+}
+
+// fill receives the next non-empty chunk unless the buffer holds data
+func (r *submitFileReader) fill() error {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err == io.EOF {
+			return io.EOF
+		}
+		if err != nil {
+			r.err = err
+			return err
+		}
+		if req.GetMetadata() != nil {
+			r.err = status.Error(codes.InvalidArgument, "metadata after content")
+			return r.err
+		}
+		r.buf = req.GetChunk()
+	}
+	return nil
+}
+
+func (r *submitFileReader) Read(p []byte) (int, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+	read := copy(p, r.buf)
+	r.buf = r.buf[read:]
+	return read, nil
+}
+
+func (c *clientAPI) GrantFabrication(ctx context.Context, req *GrantFabricationRequest) (*SubmitResponse, error) {
+	if validateFileHash(req.FileHash) != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid file hash")
+	}
+
+	var address FabricationDataHash
+	copy(address[:], req.FileHash)
+	wrappedKey, err := c.node.app.rewrapFileKey(address, NodeID(req.AllowedNode))
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	reqID, err := c.node.app.submitPayload(AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+		FileHash:    req.FileHash,
+		AllowedNode: req.AllowedNode,
+		Count:       req.Count,
+		ValidFrom:   req.ValidFrom,
+		ValidUntil:  req.ValidUntil,
+		WrappedKey:  wrappedKey,
+	}})
+	if err != nil {
+		return nil, submitStatus(err)
+	}
+
+	return &SubmitResponse{RequestId: reqID}, nil
+}
+
+func (c *clientAPI) QueryAvailable(ctx context.Context, _ *emptypb.Empty) (*AvailableList, error) {
+	state := c.node.cb.state
+
+	list := &AvailableList{}
+	for _, allow := range state.available(c.node.id, estimatedLedgerTime(state.Time())) {
+		list.Allowances = append(list.Allowances, &Allowance{
+			FileHash:        append([]byte(nil), allow.FileHash[:]...),
+			OriginatingNode: uint64(allow.Owner),
+			Remaining:       uint32(allow.RemainingCount),
+			Pending:         uint32(allow.ReservedCount),
+			Consumed:        uint32(allow.ConsumedCount),
+			Revoked:         allow.Revoked,
+			ValidFrom:       allow.ValidFrom,
+			ValidUntil:      allow.ValidUntil,
+		})
+	}
+
+	return list, nil
+}
+
+// blockMessage converts a committed record, optionally including requests and signatures
+func blockMessage(record *AppRecord, full bool) *Block {
+	md := recordMetadata(record)
+	block := &Block{
+		Sequence:  md.LatestSequence,
+		ViewId:    md.ViewId,
+		Hash:      record.Hash(),
+		PrevHash:  record.PrevHash,
+		Timestamp: record.Timestamp(),
+	}
+	if !full {
+		return block
+	}
+
+	for _, reqBytes := range record.Batch.Requests {
+		if tx := transactionMessage(reqBytes); tx != nil {
+			block.Transactions = append(block.Transactions, tx)
+		}
+	}
+	for _, sig := range record.Signatures {
+		block.Signatures = append(block.Signatures, &ConsenterSignature{
+			Id:    sig.ID,
+			Value: sig.Value,
+			Msg:   sig.Msg,
+		})
+	}
+	return block
+}
+
+// transactionMessage converts a committed request, nil if it cannot be parsed
+func transactionMessage(reqBytes []byte) *Transaction {
+	request, err := parseRequest(reqBytes)
+	if err != nil {
+		return nil
+	}
+
+	tx := &Transaction{
+		Id:       request.ID,
+		ClientId: request.ClientID,
+		Type:     request.Type.String(),
+	}
+	if request.Type != SystemReserved {
+		// Undecodable payloads are left unset
+		tx.Payload, _ = request.decodePayload()
+	}
+	return tx
+}
+
+func (c *clientAPI) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	cb := c.node.cb
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	if len(cb.records) == 0 {
+		return nil, status.Error(codes.NotFound, "no blocks committed")
+	}
+
+	position := len(cb.records) - 1
+	if req.Sequence != 0 {
+		position = cb.recordPosition(req.Sequence)
+		if position == len(cb.records) || recordMetadata(cb.records[position]).LatestSequence != req.Sequence {
+			return nil, status.Errorf(codes.NotFound, "block %d not found", req.Sequence)
+		}
+	}
+
+	return blockMessage(cb.records[position], true), nil
+}
+
+func (c *clientAPI) WatchEvents(req *WatchEventsRequest, stream ClientAPI_WatchEventsServer) error {
+	var filter map[string]bool
+	if len(req.Types) > 0 {
+		filter = make(map[string]bool)
+		for _, t := range req.Types {
+			filter[t] = true
+		}
+	}
+
+	events := c.events.subscribe()
+	defer c.events.unsubscribe(events)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "event stream fell behind")
+			}
+			if filter != nil && !filter[event.Type] {
+				continue
+			}
+
+			msg := &Event{
+				Type:     event.Type,
+				Sequence: event.Sequence,
+			}
+			if event.request == nil {
+				msg.Event = &Event_Block{Block: blockMessage(event.record, false)}
+			} else {
+				msg.Event = &Event_Transaction{Transaction: transactionMessage(event.request)}
+			}
+
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientAPIAuthorize(t *testing.T) {
+	ca := newTestCA(t)
+	app := newTestApp(t, ca, 1, 1)
+	api := &clientAPI{node: app.Node}
+
+	providerCert, _ := ca.issue(t, "carol", string(RoleDataProvider))
+	manufacturerCert, _ := ca.issue(t, "dave", string(RoleManufacturer))
+	nodeCert, _ := ca.issue(t, "node2")
+
+	withState := func(state *tls.ConnectionState) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: *state}})
+	}
+
+	for _, tc := range []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{"provider submits file", withState(verifiedConnection(providerCert)), "/fabrico.ClientAPI/SubmitFile", codes.OK},
+		{"provider queries allowances", withState(verifiedConnection(providerCert)), "/fabrico.ClientAPI/QueryAvailable", codes.PermissionDenied},
+		{"manufacturer queries allowances", withState(verifiedConnection(manufacturerCert)), "/fabrico.ClientAPI/QueryAvailable", codes.OK},
+		{"manufacturer grants fabrication", withState(verifiedConnection(manufacturerCert)), "/fabrico.ClientAPI/GrantFabrication", codes.PermissionDenied},
+		{"manufacturer reads block", withState(verifiedConnection(manufacturerCert)), "/fabrico.ClientAPI/GetBlock", codes.OK},
+		{"unlisted method", withState(verifiedConnection(providerCert)), "/fabrico.ClientAPI/Unknown", codes.PermissionDenied},
+		{"node certificate", withState(verifiedConnection(nodeCert)), "/fabrico.ClientAPI/GetBlock", codes.PermissionDenied},
+		{"without certificate", withState(&tls.ConnectionState{}), "/fabrico.ClientAPI/GetBlock", codes.Unauthenticated},
+		{"without peer", context.Background(), "/fabrico.ClientAPI/GetBlock", codes.Unauthenticated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := api.authorize(tc.ctx, tc.method)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("Code %v, expected %v: %v", code, tc.code, err)
+			}
+		})
+	}
+}
+
+// testSubmitFileStream replays the messages of a client followed by the given error
+type testSubmitFileStream struct {
+	grpc.ServerStream
+	messages []*SubmitFileRequest
+	err      error
+}
+
+func (s *testSubmitFileStream) Recv() (*SubmitFileRequest, error) {
+	if len(s.messages) == 0 {
+		return nil, s.err
+	}
+	message := s.messages[0]
+	s.messages = s.messages[1:]
+	return message, nil
+}
+
+func (s *testSubmitFileStream) SendAndClose(*SubmitFileResponse) error {
+	return nil
+}
+
+func TestReceiveSubmitFile(t *testing.T) {
+	metadata := &SubmitFileRequest{Part: &SubmitFileRequest_Metadata{Metadata: &SubmitFileMetadata{AllowedNodes: []uint64{2, 3}, Count: 5}}}
+	chunk := func(data string) *SubmitFileRequest {
+		return &SubmitFileRequest{Part: &SubmitFileRequest_Chunk{Chunk: []byte(data)}}
+	}
+	streamErr := status.Error(codes.Canceled, "context canceled")
+
+	for _, tc := range []struct {
+		name      string
+		messages  []*SubmitFileRequest
+		err       error
+		code      codes.Code // of receiving the metadata
+		content   string
+		streamErr error // of reading the content
+	}{
+		{"chunks", []*SubmitFileRequest{metadata, chunk("G1 X10"), chunk(""), chunk(" Y10\n")}, io.EOF, codes.OK, "G1 X10 Y10\n", nil},
+		{"missing metadata", []*SubmitFileRequest{chunk("G1 X10")}, io.EOF, codes.InvalidArgument, "", nil},
+		{"empty stream", nil, io.EOF, codes.InvalidArgument, "", nil},
+		{"empty content", []*SubmitFileRequest{metadata, chunk("")}, io.EOF, codes.InvalidArgument, "", nil},
+		{"metadata after content", []*SubmitFileRequest{metadata, chunk("G1 X10"), metadata}, io.EOF, codes.OK, "G1 X10", status.Error(codes.InvalidArgument, "metadata after content")},
+		{"stream error", []*SubmitFileRequest{metadata, chunk("G1 X10")}, streamErr, codes.OK, "G1 X10", streamErr},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			received, content, err := receiveSubmitFile(&testSubmitFileStream{messages: tc.messages, err: tc.err})
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("Code %v, expected %v: %v", code, tc.code, err)
+			}
+			if err != nil {
+				return
+			}
+			if received.Count != 5 || len(received.AllowedNodes) != 2 {
+				t.Fatalf("Received metadata %v", received)
+			}
+
+			read, err := io.ReadAll(content)
+			if !bytes.Equal(read, []byte(tc.content)) {
+				t.Fatalf("Read %q, expected %q", read, tc.content)
+			}
+			if tc.streamErr == nil && err != nil {
+				t.Fatal(err)
+			}
+			if status.Code(content.err) != status.Code(tc.streamErr) {
+				t.Fatalf("Content error %v, expected %v", content.err, tc.streamErr)
+			}
+		})
+	}
+}
@@ -1,19 +1,50 @@
 package ecies
 
 import (
+	"bytes"
 	"encoding/asn1"
 	"errors"
+	"io"
 
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/curve25519"
 	"golang.org/x/crypto/sha3"
 )
 
+// Decrypt decrypts version 1 messages, version 2 envelopes and version 3 streams
+// without associated data. Signed version 4 messages require DecryptVerify.
 func Decrypt(priv []byte, msg []byte) ([]byte, error) {
+	return DecryptWithAssociatedData(priv, msg, nil)
+}
+
+// DecryptWithAssociatedData decrypts messages authenticating the given associated data.
+// Version 1 messages cannot authenticate associated data and are only accepted without.
+func DecryptWithAssociatedData(priv []byte, msg []byte, associatedData []byte) ([]byte, error) {
 	if len(priv) != curve25519.ScalarSize {
 		return nil, errors.New("invalid scalar size")
 	}
 
+	version, err := messageVersion(msg)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case 2:
+		return DecryptEnvelope(priv, msg, associatedData)
+	case 3:
+		plaintext, err := DecryptStreamWith(bytes.NewReader(msg), priv, associatedData)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(plaintext)
+	case 4:
+		return nil, ErrSigncrypted
+	}
+
+	if len(associatedData) > 0 {
+		return nil, errors.New("associated data requires message version 2 or later")
+	}
+
 	ecieMsg := &ECIEMessage{}
 	rest, err := asn1.Unmarshal(msg, ecieMsg)
 	if err != nil {
@@ -28,6 +59,29 @@ func Decrypt(priv []byte, msg []byte) ([]byte, error) {
 		return nil, errors.New("unknown encrypted message algorithm version")
 	}
 
+	return open(priv, ecieMsg)
+}
+
+// messageVersion returns the version of an ASN.1 encoded message or envelope,
+// which is the first element of its sequence
+func messageVersion(msg []byte) (int, error) {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(msg, &raw); err != nil {
+		return 0, err
+	}
+	if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+		return 0, errors.New("invalid message encoding")
+	}
+
+	var version int
+	if _, err := asn1.Unmarshal(raw.Bytes, &version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// open decrypts a version 1 message
+func open(priv []byte, ecieMsg *ECIEMessage) ([]byte, error) {
 	sharedSecret, err := curve25519.X25519(priv, ecieMsg.EphemeralPublic)
 	if err != nil {
 		return nil, err
@@ -44,7 +98,7 @@ func Decrypt(priv []byte, msg []byte) ([]byte, error) {
 
 	// Decryption
 	if len(ecieMsg.Nonce) < aead.NonceSize() {
-		panic("nonce too short")
+		return nil, errors.New("nonce too short")
 	}
 
 	// Decrypt the message and check it wasn't tampered with.
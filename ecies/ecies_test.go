@@ -60,3 +60,91 @@ func TestECIESFromEd25519(t *testing.T) {
 	}
 
 }
+
+func TestDecryptVersion1(t *testing.T) {
+	keys := generateTestKeys(t, 1)
+	testMsg := randomMessage(t, 1<<10)
+
+	encryptedTestMsg, err := Encrypt(keys[0].public, append([]byte(nil), testMsg...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptedTestMsg, err := Decrypt(keys[0].private, encryptedTestMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(testMsg, decryptedTestMsg) {
+		t.Fatal("Decryption mismatch")
+	}
+
+	// Version 1 cannot authenticate associated data
+	if _, err := DecryptWithAssociatedData(keys[0].private, encryptedTestMsg, []byte("context")); err == nil {
+		t.Fatal("Version 1 message accepted with associated data")
+	}
+}
+
+func TestAssociatedData(t *testing.T) {
+	keys := generateTestKeys(t, 1)
+	testMsg := randomMessage(t, 1<<10)
+	fileHash := randomMessage(t, 64)
+
+	encryptedTestMsg, err := EncryptWithAssociatedData(keys[0].public, testMsg, fileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptedTestMsg, err := DecryptWithAssociatedData(keys[0].private, encryptedTestMsg, fileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(testMsg, decryptedTestMsg) {
+		t.Fatal("Decryption mismatch")
+	}
+
+	otherHash := append([]byte(nil), fileHash...)
+	otherHash[0] ^= 1
+
+	var tests = map[string][]byte{
+		"missing": nil,
+		"other":   otherHash,
+		"longer":  append(append([]byte(nil), fileHash...), 0),
+	}
+
+	for name, associatedData := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecryptWithAssociatedData(keys[0].private, encryptedTestMsg, associatedData); err == nil {
+				t.Fatal("Decrypted with wrong associated data")
+			}
+		})
+	}
+}
+
+func TestKeyBinding(t *testing.T) {
+	keys := generateTestKeys(t, 2)
+	testMsg := randomMessage(t, 1<<10)
+
+	envelope, err := EncryptEnvelope(publicKeys(keys), testMsg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wrapped keys are bound to the recipient key ID and ephemeral key they were created with
+	env, err := UnmarshalECIEEnvelope(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Recipients[0].KeyID, env.Recipients[1].KeyID = env.Recipients[1].KeyID, env.Recipients[0].KeyID
+	if _, err := DecryptEnvelope(keys[0].private, MarshalECIEEnvelope(env), nil); err == nil {
+		t.Fatal("Decrypted key wrapped for another recipient")
+	}
+
+	env, err = UnmarshalECIEEnvelope(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Recipients[0].EphemeralPublic = env.Recipients[1].EphemeralPublic
+	if _, err := DecryptEnvelope(keys[0].private, MarshalECIEEnvelope(env), nil); err == nil {
+		t.Fatal("Decrypted with replaced ephemeral key")
+	}
+}
@@ -10,7 +10,25 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// Encrypt encrypts msg to the public key as version 1 message
+//
+// Deprecated: version 1 binds neither the keys nor any context, use EncryptWithAssociatedData.
 func Encrypt(publicKey []byte, msg []byte) ([]byte, error) {
+	ecieMsg, err := seal(publicKey, msg)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(*ecieMsg)
+}
+
+// EncryptWithAssociatedData encrypts msg to the public key as version 2 envelope with a single recipient.
+// The associated data, such as the hash of the protected file, is authenticated but not encrypted.
+func EncryptWithAssociatedData(publicKey []byte, msg []byte, associatedData []byte) ([]byte, error) {
+	return EncryptEnvelope([][]byte{publicKey}, msg, associatedData)
+}
+
+// seal encrypts msg to the public key using version 1, leaving msg intact
+func seal(publicKey []byte, msg []byte) (*ECIEMessage, error) {
 	if len(publicKey) != curve25519.ScalarSize {
 		return nil, errors.New("invalid public key")
 	}
@@ -46,10 +64,10 @@ func Encrypt(publicKey []byte, msg []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return asn1.Marshal(ECIEMessage{
+	return &ECIEMessage{
 		Version:         1,
 		EphemeralPublic: ephemeralPublic,
 		Nonce:           nonce,
-		Ciphertext:      aead.Seal(msg[:0], nonce, msg, nil),
-	})
+		Ciphertext:      aead.Seal(nil, nonce, msg, nil),
+	}, nil
 }
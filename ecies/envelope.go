@@ -0,0 +1,237 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/sha3"
+)
+
+// Version 2:
+// Random content key encrypting the payload once with ChaCha20-Poly1305 with extended nonce
+// Content key wrapped for each recipient, identified by recipient key ID, see kdf.go
+// Payload and wrapped keys authenticate the associated data supplied by the caller
+// Recipients can be added or removed without re-encrypting the payload
+
+const keyIDSize = 16
+
+type ECIEEnvelope struct {
+	Version    int // ASN1 cannot serialize uint
+	Recipients []ECIERecipient
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+type ECIERecipient struct {
+	KeyID           []byte // see KeyID
+	EphemeralPublic []byte // Curve25519 / Montgomery
+	Nonce           []byte
+	WrappedKey      []byte
+}
+
+var (
+	ErrNotRecipient     = errors.New("private key is not a recipient of the envelope")
+	ErrDuplicateKey     = errors.New("recipient already present in envelope")
+	ErrUnknownRecipient = errors.New("recipient not present in envelope")
+)
+
+// KeyID identifies a Curve25519 / Montgomery public key as a recipient
+func KeyID(publicKey []byte) []byte {
+	hash := sha3.Sum256(publicKey)
+	return hash[:keyIDSize]
+}
+
+func MarshalECIEEnvelope(env *ECIEEnvelope) []byte {
+	out, err := asn1.Marshal(*env)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func UnmarshalECIEEnvelope(in []byte) (*ECIEEnvelope, error) {
+	return unmarshalEnvelope(in, 2)
+}
+
+// unmarshalEnvelope parses an envelope of the given version, see signcrypt.go for version 4
+func unmarshalEnvelope(in []byte, version int) (*ECIEEnvelope, error) {
+	env := &ECIEEnvelope{}
+	rest, err := asn1.Unmarshal(in, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("unexpected trailing data")
+	}
+	if env.Version != version || len(env.Nonce) != chacha20poly1305.NonceSizeX || len(env.Ciphertext) == 0 {
+		return nil, errors.New("invalid envelope encoding")
+	}
+	for _, r := range env.Recipients {
+		if len(r.KeyID) != keyIDSize || len(r.EphemeralPublic) != curve25519.PointSize || len(r.Nonce) != chacha20poly1305.NonceSizeX {
+			return nil, errors.New("invalid recipient encoding")
+		}
+	}
+
+	return env, nil
+}
+
+// EncryptEnvelope encrypts the message once to all given Curve25519 / Montgomery public keys.
+// The associated data is authenticated but not encrypted, it must be supplied again for decryption.
+func EncryptEnvelope(publicKeys [][]byte, msg []byte, associatedData []byte) ([]byte, error) {
+	return encryptEnvelope(2, publicKeys, msg, associatedData)
+}
+
+func encryptEnvelope(version int, publicKeys [][]byte, msg []byte, associatedData []byte) ([]byte, error) {
+	contentKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	env := &ECIEEnvelope{
+		Version:    version,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, msg, associatedData),
+	}
+
+	for _, publicKey := range publicKeys {
+		if err := env.addRecipient(contentKey, publicKey, associatedData); err != nil {
+			return nil, err
+		}
+	}
+
+	return MarshalECIEEnvelope(env), nil
+}
+
+// DecryptEnvelope decrypts the envelope with the private key of one of its recipients
+func DecryptEnvelope(priv []byte, in []byte, associatedData []byte) ([]byte, error) {
+	env, err := UnmarshalECIEEnvelope(in)
+	if err != nil {
+		return nil, err
+	}
+	return env.open(priv, associatedData)
+}
+
+// AddRecipient wraps the content key of the envelope for an additional public key,
+// using the private key of an existing recipient. The payload ciphertext is unchanged.
+func AddRecipient(priv []byte, in []byte, publicKey []byte, associatedData []byte) ([]byte, error) {
+	env, err := UnmarshalECIEEnvelope(in)
+	if err != nil {
+		return nil, err
+	}
+
+	contentKey, err := env.contentKey(priv, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.addRecipient(contentKey, publicKey, associatedData); err != nil {
+		return nil, err
+	}
+	return MarshalECIEEnvelope(env), nil
+}
+
+// RemoveRecipient drops the wrapped content key of the given public key from the envelope.
+// A removed recipient which kept the content key or an earlier envelope can still decrypt the payload.
+func RemoveRecipient(in []byte, publicKey []byte) ([]byte, error) {
+	env, err := UnmarshalECIEEnvelope(in)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := KeyID(publicKey)
+	for i, r := range env.Recipients {
+		if bytes.Equal(r.KeyID, keyID) {
+			env.Recipients = append(env.Recipients[:i], env.Recipients[i+1:]...)
+			return MarshalECIEEnvelope(env), nil
+		}
+	}
+	return nil, ErrUnknownRecipient
+}
+
+func (env *ECIEEnvelope) addRecipient(contentKey []byte, publicKey []byte, associatedData []byte) error {
+	recipient, err := wrapKey(env.Recipients, contentKey, publicKey, associatedData)
+	if err != nil {
+		return err
+	}
+	env.Recipients = append(env.Recipients, recipient)
+	return nil
+}
+
+// open decrypts the payload with the private key of one of the recipients
+func (env *ECIEEnvelope) open(priv []byte, associatedData []byte) ([]byte, error) {
+	contentKey, err := env.contentKey(priv, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, env.Nonce, env.Ciphertext, associatedData)
+}
+
+// contentKey unwraps the content key for the given private key
+func (env *ECIEEnvelope) contentKey(priv []byte, associatedData []byte) ([]byte, error) {
+	return unwrapKey(env.Recipients, priv, associatedData)
+}
+
+// wrapKey wraps the content key for a public key not yet among the recipients
+func wrapKey(recipients []ECIERecipient, contentKey []byte, publicKey []byte, associatedData []byte) (ECIERecipient, error) {
+	if len(publicKey) != curve25519.PointSize {
+		return ECIERecipient{}, errors.New("invalid public key")
+	}
+
+	keyID := KeyID(publicKey)
+	for _, r := range recipients {
+		if bytes.Equal(r.KeyID, keyID) {
+			return ECIERecipient{}, ErrDuplicateKey
+		}
+	}
+
+	ephemeralPublic, nonce, wrapped, err := wrapContentKey(publicKey, contentKey, associatedData)
+	if err != nil {
+		return ECIERecipient{}, err
+	}
+
+	return ECIERecipient{
+		KeyID:           keyID,
+		EphemeralPublic: ephemeralPublic,
+		Nonce:           nonce,
+		WrappedKey:      wrapped,
+	}, nil
+}
+
+// unwrapKey unwraps the content key from the recipient matching the private key
+func unwrapKey(recipients []ECIERecipient, priv []byte, associatedData []byte) ([]byte, error) {
+	if len(priv) != curve25519.ScalarSize {
+		return nil, errors.New("invalid scalar size")
+	}
+
+	publicKey, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := KeyID(publicKey)
+	for i := range recipients {
+		if bytes.Equal(recipients[i].KeyID, keyID) {
+			return unwrapContentKey(priv, publicKey, &recipients[i], associatedData)
+		}
+	}
+	return nil, ErrNotRecipient
+}
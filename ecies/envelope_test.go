@@ -0,0 +1,214 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testKey struct {
+	public  []byte
+	private []byte
+}
+
+func generateTestKeys(t *testing.T, count int) []testKey {
+	var keys []testKey
+	for i := 0; i < count; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pubMontgomery, err := PublicEd25519ToMontgomery(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		privMontgomery, err := PrivateEd25519ToMontgomery(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys = append(keys, testKey{public: pubMontgomery, private: privMontgomery})
+	}
+	return keys
+}
+
+func publicKeys(keys []testKey) [][]byte {
+	var out [][]byte
+	for _, key := range keys {
+		out = append(out, key.public)
+	}
+	return out
+}
+
+func randomMessage(t *testing.T, length int) []byte {
+	msg := make([]byte, length)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func envelopeBody(t *testing.T, in []byte) ([]byte, []byte) {
+	env, err := UnmarshalECIEEnvelope(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env.Nonce, env.Ciphertext
+}
+
+func TestEnvelopeRecipients(t *testing.T) {
+
+	var tests = []int{1, 2, 5}
+
+	for _, count := range tests {
+		testname := fmt.Sprintf("%d", count)
+		t.Run(testname, func(t *testing.T) {
+			keys := generateTestKeys(t, count)
+			testMsg := randomMessage(t, 1<<12)
+
+			envelope, err := EncryptEnvelope(publicKeys(keys), testMsg, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range keys {
+				decryptedTestMsg, err := DecryptEnvelope(key.private, envelope, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(testMsg, decryptedTestMsg) {
+					t.Fatal("Decryption mismatch")
+				}
+
+				// Decrypt accepts envelopes as well
+				decryptedTestMsg, err = Decrypt(key.private, envelope)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(testMsg, decryptedTestMsg) {
+					t.Fatal("Decryption mismatch")
+				}
+			}
+
+			outsider := generateTestKeys(t, 1)[0]
+			if _, err := DecryptEnvelope(outsider.private, envelope, nil); !errors.Is(err, ErrNotRecipient) {
+				t.Fatalf("Expected ErrNotRecipient, got %v", err)
+			}
+		})
+	}
+
+}
+
+func TestEnvelopeAddRecipient(t *testing.T) {
+	keys := generateTestKeys(t, 3)
+	testMsg := randomMessage(t, 1<<10)
+
+	envelope, err := EncryptEnvelope(publicKeys(keys[:2]), testMsg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptEnvelope(keys[2].private, envelope, nil); !errors.Is(err, ErrNotRecipient) {
+		t.Fatalf("Expected ErrNotRecipient before adding, got %v", err)
+	}
+
+	extended, err := AddRecipient(keys[1].private, envelope, keys[2].public, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce, ciphertext := envelopeBody(t, envelope)
+	extendedNonce, extendedCiphertext := envelopeBody(t, extended)
+	if !bytes.Equal(nonce, extendedNonce) || !bytes.Equal(ciphertext, extendedCiphertext) {
+		t.Fatal("Payload was re-encrypted")
+	}
+
+	for _, key := range keys {
+		decryptedTestMsg, err := DecryptEnvelope(key.private, extended, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(testMsg, decryptedTestMsg) {
+			t.Fatal("Decryption mismatch")
+		}
+	}
+
+	if _, err := AddRecipient(keys[0].private, extended, keys[2].public, nil); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Expected ErrDuplicateKey, got %v", err)
+	}
+
+	outsider := generateTestKeys(t, 1)[0]
+	if _, err := AddRecipient(outsider.private, envelope, outsider.public, nil); !errors.Is(err, ErrNotRecipient) {
+		t.Fatalf("Expected ErrNotRecipient for adding without access, got %v", err)
+	}
+}
+
+func TestEnvelopeRemoveRecipient(t *testing.T) {
+	keys := generateTestKeys(t, 3)
+	testMsg := randomMessage(t, 1<<10)
+
+	envelope, err := EncryptEnvelope(publicKeys(keys), testMsg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reduced, err := RemoveRecipient(envelope, keys[1].public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce, ciphertext := envelopeBody(t, envelope)
+	reducedNonce, reducedCiphertext := envelopeBody(t, reduced)
+	if !bytes.Equal(nonce, reducedNonce) || !bytes.Equal(ciphertext, reducedCiphertext) {
+		t.Fatal("Payload was re-encrypted")
+	}
+
+	if _, err := DecryptEnvelope(keys[1].private, reduced, nil); !errors.Is(err, ErrNotRecipient) {
+		t.Fatalf("Expected ErrNotRecipient after removal, got %v", err)
+	}
+
+	for _, key := range []testKey{keys[0], keys[2]} {
+		decryptedTestMsg, err := DecryptEnvelope(key.private, reduced, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(testMsg, decryptedTestMsg) {
+			t.Fatal("Decryption mismatch")
+		}
+	}
+
+	if _, err := RemoveRecipient(reduced, keys[1].public); !errors.Is(err, ErrUnknownRecipient) {
+		t.Fatalf("Expected ErrUnknownRecipient, got %v", err)
+	}
+}
+
+func TestEnvelopeTampered(t *testing.T) {
+	keys := generateTestKeys(t, 2)
+	testMsg := randomMessage(t, 1<<10)
+
+	envelope, err := EncryptEnvelope(publicKeys(keys), testMsg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := UnmarshalECIEEnvelope(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Ciphertext[0] ^= 1
+
+	if _, err := DecryptEnvelope(keys[0].private, MarshalECIEEnvelope(env), nil); err == nil {
+		t.Fatal("Tampered payload decrypted")
+	}
+
+	env.Ciphertext[0] ^= 1
+	env.Recipients[0].WrappedKey[0] ^= 1
+	if _, err := DecryptEnvelope(keys[0].private, MarshalECIEEnvelope(env), nil); err == nil {
+		t.Fatal("Tampered wrapped key decrypted")
+	}
+}
@@ -0,0 +1,92 @@
+package ecies
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/sha3"
+)
+
+// Version 2 key wrapping:
+// X25519 with ephemeral key as in version 1
+// HKDF-SHA3-256 over the shared secret, with ephemeral and recipient public key as context
+// ChaCha20-Poly1305 with extended nonce, authenticating caller supplied associated data
+
+var kdfInfo = []byte("fabrico-ledger ecies v2")
+
+// deriveKey derives the key encryption key from the X25519 shared secret,
+// binding it to the ephemeral and recipient public key
+func deriveKey(sharedSecret, ephemeralPublic, recipientPublic []byte) ([]byte, error) {
+	info := make([]byte, 0, len(kdfInfo)+len(ephemeralPublic)+len(recipientPublic))
+	info = append(info, kdfInfo...)
+	info = append(info, ephemeralPublic...)
+	info = append(info, recipientPublic...)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha3.New256, sharedSecret, nil, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// wrapContentKey encrypts the content key to the public key
+func wrapContentKey(publicKey, contentKey, associatedData []byte) (ephemeralPublic, nonce, wrapped []byte, err error) {
+	ephemeralPrivate := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeralPrivate); err != nil {
+		return nil, nil, nil, err
+	}
+
+	ephemeralPublic, err = curve25519.X25519(ephemeralPrivate, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sharedSecret, err := curve25519.X25519(ephemeralPrivate, publicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	encKey, err := deriveKey(sharedSecret, ephemeralPublic, publicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(encKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ephemeralPublic, nonce, aead.Seal(nil, nonce, contentKey, associatedData), nil
+}
+
+// unwrapContentKey decrypts a content key wrapped for the public key of priv
+func unwrapContentKey(priv, publicKey []byte, r *ECIERecipient, associatedData []byte) ([]byte, error) {
+	if len(r.EphemeralPublic) != curve25519.PointSize || len(r.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, errors.New("invalid recipient encoding")
+	}
+
+	sharedSecret, err := curve25519.X25519(priv, r.EphemeralPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	encKey, err := deriveKey(sharedSecret, r.EphemeralPublic, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(encKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, r.Nonce, r.WrappedKey, associatedData)
+}
@@ -0,0 +1,143 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Version 4:
+// Sign-then-encrypt, a version 2 envelope whose payload is a SignedMessage
+// The sender signs the SHA3-512 digest of the associated data, the sorted recipient key IDs and plaintext
+// with the Ed25519 key of its certificate, which is embedded for verification against a CA pool by the recipient.
+// Binding the associated data and recipients prevents recipients from forwarding the signed plaintext
+// in another context or to other recipients.
+
+type SignedMessage struct {
+	Version     int
+	Payload     []byte
+	Signature   []byte
+	Certificate []byte // x509 DER of the sender
+}
+
+var ErrSigncrypted = errors.New("message is signed, use DecryptVerify")
+
+var signatureDomain = []byte("fabrico-ledger ecies signcryption v4")
+
+// signedDigest returns the digest signed by the sender for the given recipient key IDs
+func signedDigest(msg []byte, associatedData []byte, keyIDs [][]byte) []byte {
+	hash := sha3.New512()
+	hash.Write(signatureDomain)
+
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(associatedData)))
+	hash.Write(length[:])
+	hash.Write(associatedData)
+
+	// Key IDs have a fixed size, the order of recipients in the envelope is not significant
+	sorted := make([][]byte, len(keyIDs))
+	copy(sorted, keyIDs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	binary.BigEndian.PutUint64(length[:], uint64(len(sorted)))
+	hash.Write(length[:])
+	for _, keyID := range sorted {
+		hash.Write(keyID)
+	}
+
+	hash.Write(msg)
+	return hash.Sum(nil)
+}
+
+// SignEncrypt signs the message with the private key of the sender certificate and
+// encrypts it together with the certificate to the given Curve25519 / Montgomery public keys.
+// The associated data is authenticated but not encrypted, it must be supplied again for decryption.
+func SignEncrypt(priv ed25519.PrivateKey, cert *x509.Certificate, publicKeys [][]byte, msg []byte, associatedData []byte) ([]byte, error) {
+	pub, err := PublicEd25519FromCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(pub, priv.Public().(ed25519.PublicKey)) {
+		return nil, errors.New("private key does not match certificate")
+	}
+
+	keyIDs := make([][]byte, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		keyIDs = append(keyIDs, KeyID(publicKey))
+	}
+
+	signed, err := asn1.Marshal(SignedMessage{
+		Version:     4,
+		Payload:     msg,
+		Signature:   ed25519.Sign(priv, signedDigest(msg, associatedData, keyIDs)),
+		Certificate: cert.Raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptEnvelope(4, publicKeys, signed, associatedData)
+}
+
+// DecryptVerify decrypts a signed message with the private key of one of its recipients.
+// Returns the plaintext and the sender certificate, which was verified against the given CA pool.
+// Callers must check that the certificate identifies the expected sender.
+func DecryptVerify(priv []byte, in []byte, associatedData []byte, roots *x509.CertPool) ([]byte, *x509.Certificate, error) {
+	env, err := unmarshalEnvelope(in, 4)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signed, err := env.open(priv, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signedMsg := &SignedMessage{}
+	rest, err := asn1.Unmarshal(signed, signedMsg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) > 0 {
+		return nil, nil, errors.New("unexpected trailing data")
+	}
+	if signedMsg.Version != 4 {
+		return nil, nil, errors.New("unexpected signature version")
+	}
+
+	cert, err := x509.ParseCertificate(signedMsg.Certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, nil, errors.New("failed to verify sender certificate: " + err.Error())
+	}
+
+	pub, err := PublicEd25519FromCertificate(cert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The sender signed for the recipients of this envelope, so forwarded messages fail verification
+	keyIDs := make([][]byte, 0, len(env.Recipients))
+	for _, r := range env.Recipients {
+		keyIDs = append(keyIDs, r.KeyID)
+	}
+
+	if !ed25519.Verify(pub, signedDigest(signedMsg.Payload, associatedData, keyIDs), signedMsg.Signature) {
+		return nil, nil, errors.New("invalid sender signature")
+	}
+
+	return signedMsg.Payload, cert, nil
+}
@@ -0,0 +1,211 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testSender struct {
+	cert *x509.Certificate
+	key  ed25519.PrivateKey
+}
+
+func generateTestCA(t *testing.T) (*testSender, *x509.CertPool) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &testSender{cert: cert, key: priv}, roots
+}
+
+func generateTestSender(t *testing.T, ca *testSender, name string) *testSender {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSender{cert: cert, key: priv}
+}
+
+func TestSignEncrypt(t *testing.T) {
+	ca, roots := generateTestCA(t)
+	sender := generateTestSender(t, ca, "node1")
+	keys := generateTestKeys(t, 2)
+	testMsg := randomMessage(t, 1<<12)
+	fileHash := randomMessage(t, 64)
+
+	signed, err := SignEncrypt(sender.key, sender.cert, publicKeys(keys), testMsg, fileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range keys {
+		decryptedTestMsg, cert, err := DecryptVerify(key.private, signed, fileHash, roots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(testMsg, decryptedTestMsg) {
+			t.Fatal("Decryption mismatch")
+		}
+		if cert.Subject.CommonName != "node1" {
+			t.Fatalf("Unexpected sender %s", cert.Subject.CommonName)
+		}
+	}
+
+	if _, err := Decrypt(keys[0].private, signed); !errors.Is(err, ErrSigncrypted) {
+		t.Fatalf("Expected ErrSigncrypted, got %v", err)
+	}
+
+	if _, _, err := DecryptVerify(keys[0].private, signed, nil, roots); err == nil {
+		t.Fatal("Decrypted without associated data")
+	}
+
+	outsider := generateTestKeys(t, 1)[0]
+	if _, _, err := DecryptVerify(outsider.private, signed, fileHash, roots); !errors.Is(err, ErrNotRecipient) {
+		t.Fatalf("Expected ErrNotRecipient, got %v", err)
+	}
+}
+
+func TestSignEncryptUntrustedSender(t *testing.T) {
+	ca, _ := generateTestCA(t)
+	_, otherRoots := generateTestCA(t)
+	sender := generateTestSender(t, ca, "node1")
+	keys := generateTestKeys(t, 1)
+
+	signed, err := SignEncrypt(sender.key, sender.cert, publicKeys(keys), randomMessage(t, 1<<10), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := DecryptVerify(keys[0].private, signed, nil, otherRoots); err == nil {
+		t.Fatal("Verified sender of foreign CA")
+	}
+
+	other := generateTestSender(t, ca, "node2")
+	if _, err := SignEncrypt(other.key, sender.cert, publicKeys(keys), randomMessage(t, 1<<10), nil); err == nil {
+		t.Fatal("Signed with key not matching certificate")
+	}
+}
+
+func TestSignEncryptForwarded(t *testing.T) {
+	ca, roots := generateTestCA(t)
+	sender := generateTestSender(t, ca, "node1")
+	keys := generateTestKeys(t, 2)
+	testMsg := randomMessage(t, 1<<10)
+
+	signed, err := SignEncrypt(sender.key, sender.cert, publicKeys(keys[:1]), testMsg, []byte("file 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first recipient re-encrypts the signed payload for another context and recipient
+	env, err := unmarshalEnvelope(signed, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedMsg, err := env.open(keys[0].private, []byte("file 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwarded, err := encryptEnvelope(4, publicKeys(keys[1:]), signedMsg, []byte("file 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DecryptVerify(keys[1].private, forwarded, []byte("file 2"), roots); err == nil {
+		t.Fatal("Forwarded message verified in another context")
+	}
+
+	// The signature covers the recipients, so forwarding fails in the same context as well
+	for _, tc := range []struct {
+		name       string
+		recipients []testKey
+	}{
+		{"other recipient", keys[1:]},
+		{"additional recipient", keys},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			forwarded, err := encryptEnvelope(4, publicKeys(tc.recipients), signedMsg, []byte("file 1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := DecryptVerify(keys[1].private, forwarded, []byte("file 1"), roots); err == nil {
+				t.Fatal("Forwarded message verified for another recipient")
+			}
+		})
+	}
+
+	// Re-encrypting for the original recipient still verifies
+	reencrypted, err := encryptEnvelope(4, publicKeys(keys[:1]), signedMsg, []byte("file 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DecryptVerify(keys[0].private, reencrypted, []byte("file 1"), roots); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tampered signature
+	parsed := SignedMessage{}
+	if _, err := asn1.Unmarshal(signedMsg, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	parsed.Signature[0] ^= 1
+	signedMsg, err = asn1.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered, err := encryptEnvelope(4, publicKeys(keys[:1]), signedMsg, []byte("file 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DecryptVerify(keys[0].private, tampered, []byte("file 1"), roots); err == nil {
+		t.Fatal("Tampered message verified")
+	}
+}
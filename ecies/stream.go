@@ -0,0 +1,353 @@
+package ecies
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Version 3:
+// Streaming encryption following the STREAM construction (Hoang, Reyhanitabar, Rogaway, Vizár 2015)
+// The payload is split into segments, each sealed with ChaCha20-Poly1305 with extended nonce.
+// The nonce consists of a random prefix, the big endian segment counter and a flag marking the final segment,
+// so reordered, duplicated or dropped segments and truncated streams fail authentication.
+// The ASN.1 encoded header precedes the segments. Every segment authenticates the header
+// followed by the associated data supplied by the caller.
+// The content key is either wrapped for recipients in the header as in version 2, or distributed by the caller.
+
+const (
+	StreamSegmentSize    = 64 * 1024
+	maxStreamSegmentSize = 16 * 1024 * 1024
+	maxStreamHeaderSize  = 1024 * 1024
+
+	streamNoncePrefixSize = chacha20poly1305.NonceSizeX - 5 // 4 byte counter, 1 byte final flag
+)
+
+// ErrTruncatedStream is returned if the stream ends before a valid final segment
+var ErrTruncatedStream = errors.New("truncated encrypted stream")
+
+type StreamHeader struct {
+	Version     int
+	Recipients  []ECIERecipient // empty if the content key is distributed by the caller
+	NoncePrefix []byte
+	SegmentSize int
+}
+
+type streamCipher struct {
+	associatedData []byte // stream header followed by the caller supplied associated data
+	prefix         []byte
+	counter        uint32
+	aead           cipher.AEAD
+}
+
+func (c *streamCipher) nonce(final bool) ([]byte, error) {
+	if c.counter == ^uint32(0) {
+		return nil, errors.New("encrypted stream too long")
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	copy(nonce, c.prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], c.counter)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	c.counter++
+	return nonce, nil
+}
+
+type streamWriter struct {
+	stream      streamCipher
+	w           io.Writer
+	buf         []byte
+	segmentSize int
+	closed      bool
+}
+
+// EncryptStream returns a writer encrypting to w with the given content key.
+// Close must be called to write the final segment, it does not close w.
+func EncryptStream(w io.Writer, contentKey []byte, associatedData []byte) (io.WriteCloser, error) {
+	return newStreamWriter(w, contentKey, nil, associatedData)
+}
+
+// EncryptStreamTo returns a writer encrypting to w with a random content key,
+// wrapped for each of the given Curve25519 / Montgomery public keys.
+// Close must be called to write the final segment, it does not close w.
+func EncryptStreamTo(w io.Writer, publicKeys [][]byte, associatedData []byte) (io.WriteCloser, error) {
+	contentKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+
+	var recipients []ECIERecipient
+	for _, publicKey := range publicKeys {
+		recipient, err := wrapKey(recipients, contentKey, publicKey, associatedData)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return newStreamWriter(w, contentKey, recipients, associatedData)
+}
+
+func newStreamWriter(w io.Writer, contentKey []byte, recipients []ECIERecipient, associatedData []byte) (*streamWriter, error) {
+	aead, err := chacha20poly1305.NewX(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, streamNoncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header, err := asn1.Marshal(StreamHeader{
+		Version:     3,
+		Recipients:  recipients,
+		NoncePrefix: prefix,
+		SegmentSize: StreamSegmentSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &streamWriter{
+		stream: streamCipher{
+			associatedData: append(append([]byte(nil), header...), associatedData...),
+			prefix:         prefix,
+			aead:           aead,
+		},
+		w:           w,
+		buf:         make([]byte, 0, StreamSegmentSize),
+		segmentSize: StreamSegmentSize,
+	}, nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full segment is only sealed once more data follows, the final segment is sealed on Close
+		if len(s.buf) == s.segmentSize {
+			if err := s.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(s.buf[len(s.buf):s.segmentSize], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close writes the final segment
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.seal(true)
+}
+
+func (s *streamWriter) seal(final bool) error {
+	nonce, err := s.stream.nonce(final)
+	if err != nil {
+		return err
+	}
+
+	segment := s.stream.aead.Seal(nil, nonce, s.buf, s.stream.associatedData)
+	s.buf = s.buf[:0]
+
+	_, err = s.w.Write(segment)
+	return err
+}
+
+type streamReader struct {
+	stream    streamCipher
+	r         *bufio.Reader
+	segment   []byte
+	plaintext []byte
+	done      bool
+}
+
+// DecryptStream returns a reader decrypting the stream r with the given content key.
+// Reads return ErrTruncatedStream if the stream ends before the final segment.
+func DecryptStream(r io.Reader, contentKey []byte, associatedData []byte) (io.Reader, error) {
+	reader, header, err := readStreamHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return newStreamReader(reader, header, contentKey, associatedData)
+}
+
+// DecryptStreamWith returns a reader decrypting the stream r with the private key of one of its recipients
+func DecryptStreamWith(r io.Reader, priv []byte, associatedData []byte) (io.Reader, error) {
+	reader, header, err := readStreamHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	contentKey, err := unwrapKey(header.Recipients, priv, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	return newStreamReader(reader, header, contentKey, associatedData)
+}
+
+type parsedStreamHeader struct {
+	StreamHeader
+	raw []byte
+}
+
+func readStreamHeader(r io.Reader) (*bufio.Reader, *parsedStreamHeader, error) {
+	reader := bufio.NewReader(r)
+
+	raw, err := readDER(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := &parsedStreamHeader{raw: raw}
+	rest, err := asn1.Unmarshal(raw, &header.StreamHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) > 0 {
+		return nil, nil, errors.New("unexpected trailing data")
+	}
+	if header.Version != 3 || len(header.NoncePrefix) != streamNoncePrefixSize ||
+		header.SegmentSize <= 0 || header.SegmentSize > maxStreamSegmentSize {
+		return nil, nil, errors.New("invalid stream header encoding")
+	}
+
+	return reader, header, nil
+}
+
+// readDER reads a single DER encoded value with a definite length from r
+func readDER(r *bufio.Reader) ([]byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if tag != 0x30 {
+		return nil, errors.New("expected ASN.1 sequence")
+	}
+
+	lengthByte, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	out := []byte{tag, lengthByte}
+
+	length := int(lengthByte)
+	if lengthByte&0x80 != 0 {
+		lengthBytes := int(lengthByte & 0x7f)
+		if lengthBytes == 0 || lengthBytes > 3 {
+			return nil, errors.New("invalid ASN.1 length")
+		}
+		length = 0
+		for i := 0; i < lengthBytes; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b)
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxStreamHeaderSize {
+		return nil, errors.New("stream header too large")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return append(out, content...), nil
+}
+
+func newStreamReader(r *bufio.Reader, header *parsedStreamHeader, contentKey []byte, associatedData []byte) (*streamReader, error) {
+	aead, err := chacha20poly1305.NewX(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamReader{
+		stream: streamCipher{
+			associatedData: append(append([]byte(nil), header.raw...), associatedData...),
+			prefix:         header.NoncePrefix,
+			aead:           aead,
+		},
+		r:       r,
+		segment: make([]byte, header.SegmentSize+aead.Overhead()),
+	}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.plaintext) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.plaintext)
+	s.plaintext = s.plaintext[n:]
+	return n, nil
+}
+
+// open decrypts the next segment, which is final if it is shorter than a full segment
+// or no data follows it
+func (s *streamReader) open() error {
+	n, err := io.ReadFull(s.r, s.segment)
+	final := false
+	switch {
+	case err == io.EOF:
+		return ErrTruncatedStream
+	case err == io.ErrUnexpectedEOF:
+		final = true
+	case err != nil:
+		return err
+	default:
+		if _, err := s.r.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	nonce, err := s.stream.nonce(final)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := s.stream.aead.Open(s.segment[:0], nonce, s.segment[:n], s.stream.associatedData)
+	if err != nil {
+		if final {
+			// Also the result of streams truncated at a segment boundary
+			return ErrTruncatedStream
+		}
+		return err
+	}
+
+	s.plaintext = plaintext
+	s.done = final
+	return nil
+}
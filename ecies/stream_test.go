@@ -0,0 +1,264 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func encryptTestStream(t *testing.T, key []byte, msg []byte) []byte {
+	var out bytes.Buffer
+	w, err := EncryptStream(&out, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decryptTestStream(key []byte, in io.Reader) ([]byte, error) {
+	r, err := DecryptStream(in, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func randomKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// streamSegments splits an encrypted stream into its header and segments
+func streamSegments(t *testing.T, stream []byte) ([]byte, [][]byte) {
+	_, header, err := readStreamHeader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headerLength := len(header.raw)
+	segmentLength := header.SegmentSize + 16
+
+	var segments [][]byte
+	rest := stream[headerLength:]
+	for len(rest) > segmentLength {
+		segments = append(segments, rest[:segmentLength])
+		rest = rest[segmentLength:]
+	}
+	segments = append(segments, rest)
+	return stream[:headerLength], segments
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+
+	var tests = []int{
+		0,
+		1,
+		StreamSegmentSize - 1,
+		StreamSegmentSize,
+		StreamSegmentSize + 1,
+		3*StreamSegmentSize + 5,
+	}
+
+	for _, length := range tests {
+		testname := fmt.Sprintf("%d", length)
+		t.Run(testname, func(t *testing.T) {
+			key := randomKey(t)
+			testMsg := randomMessage(t, length)
+
+			stream := encryptTestStream(t, key, testMsg)
+
+			decryptedTestMsg, err := decryptTestStream(key, bytes.NewReader(stream))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(testMsg, decryptedTestMsg) {
+				t.Fatal("Decryption mismatch")
+			}
+
+			// Data arriving in small chunks, as from DownloadContent
+			decryptedTestMsg, err = decryptTestStream(key, iotest.HalfReader(iotest.OneByteReader(bytes.NewReader(stream))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(testMsg, decryptedTestMsg) {
+				t.Fatal("Decryption mismatch on chunked input")
+			}
+		})
+	}
+
+}
+
+func TestStreamSmallWrites(t *testing.T) {
+	key := randomKey(t)
+	testMsg := randomMessage(t, 2*StreamSegmentSize+100)
+
+	var out bytes.Buffer
+	w, err := EncryptStream(&out, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(testMsg); i += 777 {
+		end := i + 777
+		if end > len(testMsg) {
+			end = len(testMsg)
+		}
+		if _, err := w.Write(testMsg[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decryptedTestMsg, err := decryptTestStream(key, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(testMsg, decryptedTestMsg) {
+		t.Fatal("Decryption mismatch")
+	}
+}
+
+func TestStreamTruncation(t *testing.T) {
+	key := randomKey(t)
+	testMsg := randomMessage(t, 3*StreamSegmentSize+5)
+	stream := encryptTestStream(t, key, testMsg)
+	header, segments := streamSegments(t, stream)
+
+	var tests = map[string][]byte{
+		"header only":      header,
+		"segment boundary": bytes.Join(append([][]byte{header}, segments[:2]...), nil),
+		"within segment":   stream[:len(header)+StreamSegmentSize/2],
+		"final segment":    stream[:len(stream)-1],
+	}
+
+	for name, truncated := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decryptTestStream(key, bytes.NewReader(truncated))
+			if !errors.Is(err, ErrTruncatedStream) {
+				t.Fatalf("Expected ErrTruncatedStream, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamReordering(t *testing.T) {
+	key := randomKey(t)
+	testMsg := randomMessage(t, 3*StreamSegmentSize+5)
+	stream := encryptTestStream(t, key, testMsg)
+	header, segments := streamSegments(t, stream)
+
+	var tests = map[string][][]byte{
+		"swapped":    {segments[1], segments[0], segments[2], segments[3]},
+		"duplicated": {segments[0], segments[0], segments[1], segments[2], segments[3]},
+		"dropped":    {segments[0], segments[2], segments[3]},
+	}
+
+	for name, modified := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decryptTestStream(key, bytes.NewReader(bytes.Join(append([][]byte{header}, modified...), nil)))
+			if err == nil {
+				t.Fatal("Modified stream decrypted")
+			}
+		})
+	}
+
+	// Segments of another stream with the same key do not verify
+	other := encryptTestStream(t, key, testMsg)
+	_, otherSegments := streamSegments(t, other)
+	spliced := bytes.Join([][]byte{header, otherSegments[0], segments[1], segments[2], segments[3]}, nil)
+	if _, err := decryptTestStream(key, bytes.NewReader(spliced)); err == nil {
+		t.Fatal("Spliced stream decrypted")
+	}
+}
+
+func TestStreamRecipients(t *testing.T) {
+	keys := generateTestKeys(t, 3)
+	testMsg := randomMessage(t, StreamSegmentSize+10)
+
+	var out bytes.Buffer
+	w, err := EncryptStreamTo(&out, publicKeys(keys), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(testMsg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range keys {
+		r, err := DecryptStreamWith(bytes.NewReader(out.Bytes()), key.private, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decryptedTestMsg, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(testMsg, decryptedTestMsg) {
+			t.Fatal("Decryption mismatch")
+		}
+
+		decryptedTestMsg, err = Decrypt(key.private, out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(testMsg, decryptedTestMsg) {
+			t.Fatal("Decryption mismatch")
+		}
+	}
+
+	outsider := generateTestKeys(t, 1)[0]
+	if _, err := DecryptStreamWith(bytes.NewReader(out.Bytes()), outsider.private, nil); !errors.Is(err, ErrNotRecipient) {
+		t.Fatalf("Expected ErrNotRecipient, got %v", err)
+	}
+}
+
+func TestStreamAssociatedData(t *testing.T) {
+	key := randomKey(t)
+	testMsg := randomMessage(t, StreamSegmentSize+10)
+	fileHash := randomMessage(t, 64)
+
+	var out bytes.Buffer
+	w, err := EncryptStream(&out, key, fileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(testMsg); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := DecryptStream(bytes.NewReader(out.Bytes()), key, fileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decryptedTestMsg, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(testMsg, decryptedTestMsg) {
+		t.Fatal("Decryption mismatch")
+	}
+
+	if _, err := decryptTestStream(key, bytes.NewReader(out.Bytes())); err == nil {
+		t.Fatal("Decrypted without associated data")
+	}
+}
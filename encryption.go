@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/fabian-z/fabrico-ledger/ecies"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Fabrication data is encrypted with a random file key before it is stored,
+// so storage nodes only hold ciphertext. The file key is distributed on the ledger,
+// encrypted to the node certificate key of each node allowed to fabricate
+// and signed by the node submitting the request.
+
+var ErrNoFileKey = errors.New("no file key for this node")
+
+func newFileKey() ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// nodeCertificate loads the certificate of the given node from the CA directory,
+// verifying it was issued by our CA for that node
+func (a *App) nodeCertificate(node NodeID) (*x509.Certificate, error) {
+	name := fmt.Sprintf("node%d", node)
+	cert, err := ecies.ReadCertificateFromFile(filepath.Join(a.certDir, name+".crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     a.caCert,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, errors.New("failed to verify certificate: " + err.Error())
+	}
+	if cert.Subject.CommonName != name {
+		return nil, errors.New("unexpected certificate common name")
+	}
+	return cert, nil
+}
+
+// fileKeyContext is the associated data of a wrapped file key, binding it to the file and the receiving node
+func fileKeyContext(address FabricationDataHash, node NodeID) []byte {
+	context := make([]byte, len(address)+8)
+	copy(context, address[:])
+	binary.BigEndian.PutUint64(context[len(address):], uint64(node))
+	return context
+}
+
+// wrapFileKey signs and encrypts the file key to the certificate key of the given node
+func (a *App) wrapFileKey(address FabricationDataHash, key []byte, node NodeID) ([]byte, error) {
+	cert, err := a.nodeCertificate(node)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ecies.PublicEd25519FromCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+	pubMontgomery, err := ecies.PublicEd25519ToMontgomery(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return ecies.SignEncrypt(a.nodeKey, a.nodeCert, [][]byte{pubMontgomery}, key, fileKeyContext(address, node))
+}
+
+// unwrapFileKey decrypts a file key, verifying it was signed by the given certificate
+func (a *App) unwrapFileKey(address FabricationDataHash, wrapped []byte, signer []byte) ([]byte, error) {
+	privMontgomery, err := ecies.PrivateEd25519ToMontgomery(a.nodeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, cert, err := ecies.DecryptVerify(privMontgomery, wrapped, fileKeyContext(address, a.ID), a.caCert)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(cert.Raw, signer) {
+		return nil, errors.New("file key not signed by request certificate")
+	}
+	return key, nil
+}
+
+type signedFileKey struct {
+	wrapped []byte
+	signer  []byte // certificate of the request distributing the key
+}
+
+// fileKey returns the file key of the fabrication data, searching the ledger for the
+// latest applied request that encrypted it to this node
+func (a *App) fileKey(address FabricationDataHash) ([]byte, error) {
+	cb := a.Node.cb
+	cb.lock.RLock()
+	var wrappedKeys []signedFileKey
+	locations := cb.fileIndex[address]
+	for i := len(locations) - 1; i >= 0; i-- {
+		request, err := parseRequest(cb.records[locations[i].record].Batch.Requests[locations[i].request])
+		if err != nil {
+			continue
+		}
+		if _, skipped := cb.skipped[request.ID]; skipped {
+			continue
+		}
+		if wrapped := request.wrappedKeyFor(a.ID); wrapped != nil {
+			wrappedKeys = append(wrappedKeys, signedFileKey{wrapped: wrapped, signer: request.Certificate})
+		}
+	}
+	cb.lock.RUnlock()
+
+	for _, wrapped := range wrappedKeys {
+		key, err := a.unwrapFileKey(address, wrapped.wrapped, wrapped.signer)
+		if err == nil {
+			return key, nil
+		}
+		a.logger.Warnf("Failed to decrypt file key for %x: %v", address, err)
+	}
+	return nil, ErrNoFileKey
+}
+
+// rewrapFileKey encrypts the file key known to this node to the given node
+func (a *App) rewrapFileKey(address FabricationDataHash, node NodeID) ([]byte, error) {
+	key, err := a.fileKey(address)
+	if err != nil {
+		return nil, err
+	}
+	return a.wrapFileKey(address, key, node)
+}
+
+// wrappedKeyFor returns the file key the request encrypted to the given node, if any
+func (txn *Request) wrappedKeyFor(node NodeID) []byte {
+	if txn.Type == SystemReserved {
+		return nil
+	}
+	payload, err := txn.decodePayload()
+	if err != nil {
+		return nil
+	}
+
+	switch txn.Type {
+	case AddFile:
+		if p := payload.GetAddFile(); p != nil && NodeID(p.OriginNode) == node {
+			return p.WrappedKey
+		}
+	case AllowFabrication:
+		if p := payload.GetAllowFabrication(); p != nil && NodeID(p.AllowedNode) == node {
+			return p.WrappedKey
+		}
+	case TransferFabrication:
+		if p := payload.GetTransferFabrication(); p != nil && NodeID(p.ReceivingNode) == node {
+			return p.WrappedKey
+		}
+	}
+	return nil
+}
+
+// storeEncrypted encrypts the fabrication data with a new file key while streaming the ciphertext into the store
+func (a *App) storeEncrypted(plaintext io.Reader) (FabricationDataHash, []byte, error) {
+	key, err := newFileKey()
+	if err != nil {
+		return FabricationDataHash{}, nil, err
+	}
+
+	ciphertext, pipe := io.Pipe()
+	go func() {
+		encrypter, err := ecies.EncryptStream(pipe, key, nil)
+		if err == nil {
+			_, err = io.Copy(encrypter, plaintext)
+		}
+		if err == nil {
+			err = encrypter.Close()
+		}
+		pipe.CloseWithError(err)
+	}()
+
+	hash, err := a.Store.StoreData(ciphertext)
+	// Stops encryption if storing failed early
+	ciphertext.CloseWithError(errors.New("store closed"))
+	if err != nil {
+		return FabricationDataHash{}, nil, err
+	}
+	return hash, key, nil
+}
+
+// openDecrypted returns the decrypted fabrication data, streamed from the local store
+// or downloaded from another node
+func (a *App) openDecrypted(address FabricationDataHash) (io.ReadCloser, error) {
+	key, err := a.fileKey(address)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := a.Store.OpenData(address)
+	if err != nil {
+		encrypted, err = a.Node.downloadContent(address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := ecies.DecryptStream(encrypted, key, nil)
+	if err != nil {
+		encrypted.Close()
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{plaintext, encrypted}, nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabian-z/fabrico-ledger/ecies"
+)
+
+// writeTestCertificate stores the certificate as the certificate of the given node in the directory
+func writeTestCertificate(t *testing.T, dir string, node NodeID, cert *x509.Certificate) {
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("node%d.crt", node)), encoded, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestEncryptionApps returns apps for the given nodes, which read each other's certificates from a common directory
+func newTestEncryptionApps(t *testing.T, ca *testCA, nodes ...NodeID) map[NodeID]*App {
+	certDir := t.TempDir()
+	apps := make(map[NodeID]*App)
+	for _, id := range nodes {
+		app := newTestApp(t, ca, id, nodes...)
+		app.certDir = certDir
+		app.Store = NewMemoryStore()
+		writeTestCertificate(t, certDir, id, app.nodeCert)
+		apps[id] = app
+	}
+	return apps
+}
+
+// testAddFileWithKey returns an AddFile request of the owner for the file carrying its wrapped file key
+func testAddFileWithKey(t *testing.T, owner *App, address FabricationDataHash, wrappedKey []byte) *Request {
+	req := signTestRequest(*testRequest(t, owner.ID, AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+		FileHash:   address[:],
+		OriginNode: uint64(owner.ID),
+		WrappedKey: wrappedKey,
+	}}), owner.nodeCert, owner.nodeKey)
+	return &req
+}
+
+// testAllowWithKey returns an unbounded grant of one part of the test file carrying the wrapped file key
+func testAllowWithKey(t *testing.T, owner *App, node NodeID, wrappedKey []byte) *Request {
+	req := signTestRequest(*testRequest(t, owner.ID, AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+		FileHash:    testFile[:],
+		AllowedNode: uint64(node),
+		Count:       1,
+		WrappedKey:  wrappedKey,
+	}}), owner.nodeCert, owner.nodeKey)
+	return &req
+}
+
+func TestWrapFileKey(t *testing.T) {
+	apps := newTestEncryptionApps(t, newTestCA(t), 1, 2, 3)
+
+	key, err := newFileKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := apps[1].wrapFileKey(testFile, key, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unwrapped, err := apps[2].unwrapFileKey(testFile, wrapped, apps[1].nodeCert.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Fatal("Unwrapped key differs")
+	}
+
+	for _, id := range []NodeID{1, 3} {
+		if _, err := apps[id].unwrapFileKey(testFile, wrapped, apps[1].nodeCert.Raw); err == nil {
+			t.Fatalf("Node %d unwrapped key of node 2", id)
+		}
+	}
+
+	// The key is bound to the file, it cannot be replayed for other data
+	if _, err := apps[2].unwrapFileKey(FabricationDataHash{2}, wrapped, apps[1].nodeCert.Raw); err == nil {
+		t.Fatal("Unwrapped key for other file")
+	}
+
+	// The key must be signed by the certificate of the request carrying it
+	if _, err := apps[2].unwrapFileKey(testFile, wrapped, apps[3].nodeCert.Raw); err == nil {
+		t.Fatal("Unwrapped key signed by other node")
+	}
+}
+
+func TestNodeCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	apps := newTestEncryptionApps(t, ca, 1, 2)
+	app := apps[1]
+
+	// Certificates are only accepted if issued by our CA for the node they are stored for
+	otherCert, _ := newTestCA(t).issue(t, "node3")
+	mismatchCert, _ := ca.issue(t, "node2")
+	writeTestCertificate(t, app.certDir, 3, otherCert)
+	writeTestCertificate(t, app.certDir, 4, mismatchCert)
+
+	for _, tc := range []struct {
+		name  string
+		node  NodeID
+		valid bool
+	}{
+		{"issued for node", 2, true},
+		{"other CA", 3, false},
+		{"other node", 4, false},
+		{"missing", 5, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cert, err := app.nodeCertificate(tc.node)
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatalf("Accepted certificate %v for node %d", cert.Subject.CommonName, tc.node)
+			}
+			if _, err := app.wrapFileKey(testFile, make([]byte, 32), tc.node); (err == nil) != tc.valid {
+				t.Fatalf("Wrapping key returned %v", err)
+			}
+		})
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	apps := newTestEncryptionApps(t, newTestCA(t), 1, 2, 3)
+
+	key, err := newFileKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedKey, err := newFileKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrap := func(from *App, key []byte, node NodeID) []byte {
+		wrapped, err := from.wrapFileKey(testFile, key, node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wrapped
+	}
+
+	addFile := testAddFileWithKey(t, apps[1], testFile, wrap(apps[1], key, 1))
+	allow := testAllowWithKey(t, apps[1], 2, wrap(apps[1], key, 2))
+	// Skipped, only the originating node grants fabrication
+	forged := testAllowWithKey(t, apps[3], 2, wrap(apps[3], forgedKey, 2))
+
+	for _, app := range apps {
+		commitTestRecord(t, app, 1000, addFile)
+		commitTestRecord(t, app, 1001, allow)
+		commitTestRecord(t, app, 1002, forged)
+		if _, skipped := app.Node.cb.skipReason(forged.ID); !skipped {
+			t.Fatal("Forged grant was applied")
+		}
+	}
+
+	for _, id := range []NodeID{1, 2} {
+		fileKey, err := apps[id].fileKey(testFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fileKey, key) {
+			t.Fatalf("Node %d got key of skipped request", id)
+		}
+	}
+
+	if _, err := apps[3].fileKey(testFile); !errors.Is(err, ErrNoFileKey) {
+		t.Fatalf("Node without grant got %v, expected %v", err, ErrNoFileKey)
+	}
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	apps := newTestEncryptionApps(t, newTestCA(t), 1, 2)
+	app := apps[1]
+	plaintext := bytes.Repeat([]byte("G1 X10 Y10\n"), 3*ecies.StreamSegmentSize/10)
+
+	address, key, err := app.storeEncrypted(bytes.NewReader(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The store only holds ciphertext
+	stored, err := app.Store.OpenData(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := io.ReadAll(stored)
+	stored.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ciphertext, []byte("G1 X10 Y10")) {
+		t.Fatal("Stored data contains plaintext")
+	}
+
+	wrapped, err := app.wrapFileKey(address, key, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTestRecord(t, app, 1000, testAddFileWithKey(t, app, address, wrapped))
+
+	decrypted, err := app.openDecrypted(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decrypted.Close()
+	roundTrip, err := io.ReadAll(decrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(roundTrip, plaintext) {
+		t.Fatal("Decrypted data differs from plaintext")
+	}
+
+	// Nodes without a wrapped key on the ledger cannot open the data
+	if _, err := apps[2].openDecrypted(address); !errors.Is(err, ErrNoFileKey) {
+		t.Fatalf("Got %v, expected %v", err, ErrNoFileKey)
+	}
+}
+
+func TestSubmitFileCleanup(t *testing.T) {
+	apps := newTestEncryptionApps(t, newTestCA(t), 1, 2)
+	app := apps[1]
+
+	store := func() FabricationDataHash {
+		address, _, err := app.storeEncrypted(bytes.NewReader([]byte("G1 X10 Y10\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return address
+	}
+
+	// Data of a known file stays referenced by the ledger
+	known := store()
+	wrapped, err := app.wrapFileKey(known, make([]byte, 32), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTestRecord(t, app, 1000, testAddFileWithKey(t, app, known, wrapped))
+
+	// Without certificate of this node no request is submitted
+	os.Remove(filepath.Join(app.certDir, "node1.crt"))
+	unknown := store()
+
+	for _, tc := range []struct {
+		name    string
+		address FabricationDataHash
+		kept    bool
+	}{
+		{"unknown file", unknown, false},
+		// Allowance for node without certificate fails
+		{"known file", known, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requestIDs, err := app.submitFile(tc.address, make([]byte, 32), []NodeID{3}, 1, 0, 0)
+			if err == nil || len(requestIDs) != 0 {
+				t.Fatalf("Submitted %v, error %v", requestIDs, err)
+			}
+			reader, err := app.Store.OpenData(tc.address)
+			if err == nil {
+				reader.Close()
+			}
+			if kept := err == nil; kept != tc.kept {
+				t.Fatalf("Data kept %v, expected %v", kept, tc.kept)
+			}
+		})
+	}
+}
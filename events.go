@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Buffered events per subscriber, slower subscribers are disconnected
+const eventBufferSize = 64
+
+// Event types emitted on the event stream
+const (
+	EventBlock       = "block"       // every delivered block
+	EventFile        = "file"        // AddFile
+	EventGrant       = "grant"       // AllowFabrication, RevokeFabrication, AdjustFabrication, TransferFabrication
+	EventFabrication = "fabrication" // AnnounceFabrication, CancelFabrication, FabricationCompleted
+)
+
+type LedgerEvent struct {
+	Type     string
+	Sequence uint64
+	Data     interface{} // *BlockSummary for block events, *TransactionData otherwise
+
+	// Source of the event for non JSON subscribers
+	record  *AppRecord
+	request []byte // nil for block events
+}
+
+// eventBroker fans out events of delivered blocks to subscribers of the event stream
+type eventBroker struct {
+	lock        sync.Mutex
+	subscribers map[chan *LedgerEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[chan *LedgerEvent]struct{}),
+	}
+}
+
+func (b *eventBroker) subscribe() chan *LedgerEvent {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ch := make(chan *LedgerEvent, eventBufferSize)
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan *LedgerEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func eventType(t RequestType) (string, bool) {
+	switch t {
+	case AddFile:
+		return EventFile, true
+	case AllowFabrication, RevokeFabrication, AdjustFabrication, TransferFabrication:
+		return EventGrant, true
+	case AnnounceFabrication, CancelFabrication, FabricationCompleted:
+		return EventFabrication, true
+	}
+	return "", false
+}
+
+// publish emits a block event followed by typed events for the requests of the delivered record.
+// Publishing never blocks delivery, subscribers with full buffers are disconnected.
+func (b *eventBroker) publish(record *AppRecord) {
+	summary := blockSummary(record)
+	events := []*LedgerEvent{{Type: EventBlock, Sequence: summary.Sequence, Data: &summary, record: record}}
+
+	for _, reqBytes := range record.Batch.Requests {
+		request, err := parseRequest(reqBytes)
+		if err != nil {
+			continue
+		}
+		if t, ok := eventType(request.Type); ok {
+			events = append(events, &LedgerEvent{
+				Type:     t,
+				Sequence: summary.Sequence,
+				Data:     transactionData(record, reqBytes),
+				record:   record,
+				request:  reqBytes,
+			})
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for ch := range b.subscribers {
+		for _, event := range events {
+			select {
+			case ch <- event:
+				continue
+			default:
+			}
+			delete(b.subscribers, ch)
+			close(ch)
+			break
+		}
+	}
+}
+
+// EventStream streams ledger events as Server-Sent Events. The optional query parameter types
+// is a comma separated list of event types to receive, all types are sent by default.
+// The event ID is the block sequence, clients catch up on missed blocks via /api/blocks.
+func (a *APIServer) EventStream(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var filter map[string]bool
+	if types := req.URL.Query().Get("types"); types != "" {
+		filter = make(map[string]bool)
+		for _, t := range strings.Split(types, ",") {
+			filter[t] = true
+		}
+	}
+
+	events := a.Events.subscribe()
+	defer a.Events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Disconnected for falling behind
+				return
+			}
+			if filter != nil && !filter[event.Type] {
+				continue
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				a.Node.app.logger.Error(err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventBrokerFanOut(t *testing.T) {
+	broker := newEventBroker()
+	subscribers := []chan *LedgerEvent{broker.subscribe(), broker.subscribe(), broker.subscribe()}
+
+	addFile := testAddFile(t, 1)
+	broker.publish(testRecord(t, 1, addFile.ToBytes(), testReservedRequest(1)))
+
+	// Every subscriber receives the block event followed by the typed request events
+	for i, ch := range subscribers {
+		for _, expected := range []string{EventBlock, EventFile} {
+			select {
+			case event := <-ch:
+				if event.Type != expected || event.Sequence != 1 {
+					t.Fatalf("Subscriber %d got %s event for sequence %d, expected %s for 1", i, event.Type, event.Sequence, expected)
+				}
+			default:
+				t.Fatalf("Subscriber %d missed %s event", i, expected)
+			}
+		}
+		if len(ch) > 0 {
+			t.Fatalf("Subscriber %d got unexpected event for reserved request", i)
+		}
+	}
+
+	broker.unsubscribe(subscribers[0])
+	broker.publish(testRecord(t, 2))
+	if _, ok := <-subscribers[0]; ok {
+		t.Fatal("Unsubscribed subscriber received event")
+	}
+	for i, ch := range subscribers[1:] {
+		if event := <-ch; event.Sequence != 2 {
+			t.Fatalf("Subscriber %d got sequence %d, expected 2", i+1, event.Sequence)
+		}
+	}
+}
+
+func TestEventBrokerSlowSubscriber(t *testing.T) {
+	broker := newEventBroker()
+	slow, fast := broker.subscribe(), broker.subscribe()
+
+	// Publishing never blocks, the subscriber which does not read is disconnected once its buffer is full
+	for i := 1; i <= eventBufferSize+1; i++ {
+		broker.publish(testRecord(t, uint64(i)))
+		if event := <-fast; event.Sequence != uint64(i) {
+			t.Fatalf("Fast subscriber got sequence %d, expected %d", event.Sequence, i)
+		}
+	}
+
+	var received int
+	for range slow {
+		received++
+	}
+	if received != eventBufferSize {
+		t.Fatalf("Slow subscriber received %d events before disconnect, expected %d", received, eventBufferSize)
+	}
+
+	broker.lock.Lock()
+	_, slowSubscribed := broker.subscribers[slow]
+	_, fastSubscribed := broker.subscribers[fast]
+	broker.lock.Unlock()
+	if slowSubscribed || !fastSubscribed {
+		t.Fatalf("Slow subscriber subscribed %v, fast subscriber subscribed %v", slowSubscribed, fastSubscribed)
+	}
+
+	// Unsubscribing after the disconnect does not close the channel again
+	broker.unsubscribe(slow)
+}
+
+func TestEventStream(t *testing.T) {
+	app := newTestApp(t, newTestCA(t), 1, 1)
+	api := &APIServer{Node: app.Node, Events: newEventBroker()}
+	server := httptest.NewServer(http.HandlerFunc(api.EventStream))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?types="+EventFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content type %q, expected text/event-stream", contentType)
+	}
+
+	// The handler subscribed before flushing the headers, block events are filtered
+	api.Events.publish(testRecord(t, 7, testAddFile(t, 1).ToBytes()))
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if lines[0] != "id: 7" || lines[1] != "event: "+EventFile || !strings.HasPrefix(lines[2], "data: ") {
+		t.Fatalf("Unexpected event %q", lines)
+	}
+
+	// Closing the connection unsubscribes
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		api.Events.lock.Lock()
+		subscribers := len(api.Events.subscribers)
+		api.Events.lock.Unlock()
+		if subscribers == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriber not removed after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
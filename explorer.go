@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	explorerDefaultLimit = 20
+	explorerMaxLimit     = 100
+)
+
+type BlockSummary struct {
+	Sequence  uint64
+	ViewID    uint64
+	Hash      string // hex string of the record hash
+	PrevHash  string
+	Timestamp string // RFC 3339 in ledger time, empty for records without header
+	Requests  int
+}
+
+type BlockPage struct {
+	LatestSequence uint64
+	Blocks         []*BlockSummary // ascending by sequence
+}
+
+type SignatureData struct {
+	ConsenterID uint64
+	Value       string // hex string
+}
+
+type BlockData struct {
+	BlockSummary
+	Transactions []*TransactionData
+	Signatures   []*SignatureData
+}
+
+type TxStatusData struct {
+	TxStatus
+	Transaction *TransactionData // set once committed
+}
+
+type TransactionData struct {
+	ID        string
+	ClientID  string
+	Type      string
+	Sequence  uint64 // committing block
+	Timestamp string
+	FileHash  string          // hex string, empty if the request references no file
+	Payload   json.RawMessage // decoded TransactionPayload, null for system requests
+	Error     string          // set if the request cannot be decoded
+}
+
+// recordMetadata returns the view metadata of a committed record
+func recordMetadata(record *AppRecord) *smartbftprotos.ViewMetadata {
+	md := &smartbftprotos.ViewMetadata{}
+	if err := proto.Unmarshal(record.Metadata, md); err != nil {
+		panic(err)
+	}
+	return md
+}
+
+func blockSummary(record *AppRecord) BlockSummary {
+	md := recordMetadata(record)
+	return BlockSummary{
+		Sequence:  md.LatestSequence,
+		ViewID:    md.ViewId,
+		Hash:      fmt.Sprintf("%x", record.Hash()),
+		PrevHash:  fmt.Sprintf("%x", record.PrevHash),
+		Timestamp: formatLedgerTime(record.Timestamp()),
+		Requests:  len(record.Batch.Requests),
+	}
+}
+
+func transactionData(record *AppRecord, reqBytes []byte) *TransactionData {
+	md := recordMetadata(record)
+	tx := &TransactionData{
+		Sequence:  md.LatestSequence,
+		Timestamp: formatLedgerTime(record.Timestamp()),
+		Payload:   json.RawMessage("null"),
+	}
+
+	request, err := parseRequest(reqBytes)
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.ID = request.ID
+	tx.ClientID = request.ClientID
+	tx.Type = fmt.Sprint(request.Type)
+
+	if hash, ok := request.fileHash(); ok {
+		tx.FileHash = fmt.Sprintf("%x", hash)
+	}
+
+	if request.Type == SystemReserved {
+		return tx
+	}
+	payload, err := request.decodePayload()
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	rawPayload, err := protojson.Marshal(payload)
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.Payload = rawPayload
+	return tx
+}
+
+// recordPosition returns the position of the first record with a sequence of at least seq
+func (cb *committedBatches) recordPosition(seq uint64) int {
+	return sort.Search(len(cb.records), func(i int) bool {
+		return recordMetadata(cb.records[i]).LatestSequence >= seq
+	})
+}
+
+func queryUint(req *http.Request, key string, fallback uint64) (uint64, error) {
+	value := req.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func (a *APIServer) encodeJSON(w http.ResponseWriter, v interface{}) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(v)
+
+	if err != nil {
+		a.Node.app.logger.Error(err)
+	}
+}
+
+// Blocks lists committed blocks ascending by sequence, starting at the query parameter start.
+// Without start the latest blocks are listed. The query parameter limit sets the page size.
+func (a *APIServer) Blocks(w http.ResponseWriter, req *http.Request) {
+	limit, err := queryUint(req, "limit", explorerDefaultLimit)
+	if err != nil || limit == 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if limit > explorerMaxLimit {
+		limit = explorerMaxLimit
+	}
+
+	cb := a.Node.cb
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	var from int
+	if req.URL.Query().Get("start") == "" {
+		from = len(cb.records) - int(limit)
+		if from < 0 {
+			from = 0
+		}
+	} else {
+		start, err := queryUint(req, "start", 0)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		from = cb.recordPosition(start)
+	}
+
+	to := from + int(limit)
+	if to > len(cb.records) {
+		to = len(cb.records)
+	}
+
+	page := &BlockPage{
+		LatestSequence: cb.latestMD.LatestSequence,
+		Blocks:         make([]*BlockSummary, 0, to-from),
+	}
+	for _, record := range cb.records[from:to] {
+		summary := blockSummary(record)
+		page.Blocks = append(page.Blocks, &summary)
+	}
+
+	a.encodeJSON(w, page)
+}
+
+// Block returns the block with the sequence given by the query parameter seq
+// including decoded requests and consenter signatures
+func (a *APIServer) Block(w http.ResponseWriter, req *http.Request) {
+	seq, err := queryUint(req, "seq", 0)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	cb := a.Node.cb
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	position := cb.recordPosition(seq)
+	if position == len(cb.records) || recordMetadata(cb.records[position]).LatestSequence != seq {
+		http.Error(w, fmt.Sprintf("Block %d not found", seq), http.StatusNotFound)
+		return
+	}
+	record := cb.records[position]
+
+	block := &BlockData{
+		BlockSummary: blockSummary(record),
+		Transactions: make([]*TransactionData, 0, len(record.Batch.Requests)),
+		Signatures:   make([]*SignatureData, 0, len(record.Signatures)),
+	}
+	for _, reqBytes := range record.Batch.Requests {
+		block.Transactions = append(block.Transactions, transactionData(record, reqBytes))
+	}
+	for _, sig := range record.Signatures {
+		block.Signatures = append(block.Signatures, &SignatureData{
+			ConsenterID: sig.ID,
+			Value:       fmt.Sprintf("%x", sig.Value),
+		})
+	}
+
+	a.encodeJSON(w, block)
+}
+
+// Transaction returns committed requests with the request UUID given by the query parameter id
+func (a *APIServer) Transaction(w http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get("id")
+
+	cb := a.Node.cb
+	cb.lock.RLock()
+	locations := cb.txIndex[id]
+	transactions := make([]*TransactionData, 0, len(locations))
+	for _, location := range locations {
+		record := cb.records[location.record]
+		transactions = append(transactions, transactionData(record, record.Batch.Requests[location.request]))
+	}
+	cb.lock.RUnlock()
+
+	if len(transactions) == 0 {
+		http.Error(w, fmt.Sprintf("Transaction %v not found", id), http.StatusNotFound)
+		return
+	}
+
+	a.encodeJSON(w, transactions)
+}
+
+// History returns all committed requests referencing the file given by the query parameter hash,
+// in order of commitment
+func (a *APIServer) History(w http.ResponseWriter, req *http.Request) {
+	hash, err := hex.DecodeString(req.URL.Query().Get("hash"))
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var address FabricationDataHash
+	if len(hash) != len(address) {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	copy(address[:], hash)
+
+	cb := a.Node.cb
+	cb.lock.RLock()
+	locations := cb.fileIndex[address]
+	transactions := make([]*TransactionData, 0, len(locations))
+	for _, location := range locations {
+		record := cb.records[location.record]
+		transactions = append(transactions, transactionData(record, record.Batch.Requests[location.request]))
+	}
+	cb.lock.RUnlock()
+
+	a.encodeJSON(w, transactions)
+}
+
+// transactionStatus returns the status of a request, either committed to the ledger
+// or submitted through this node
+func (a *APIServer) transactionStatus(id string) (*TxStatusData, bool) {
+	cb := a.Node.cb
+	cb.lock.RLock()
+	locations := cb.txIndex[id]
+	var tx *TransactionData
+	if len(locations) > 0 {
+		record := cb.records[locations[0].record]
+		tx = transactionData(record, record.Batch.Requests[locations[0].request])
+	}
+	reason, skipped := cb.skipped[id]
+	cb.lock.RUnlock()
+
+	if tx != nil {
+		status := &TxStatusData{
+			TxStatus: TxStatus{
+				ID:       id,
+				Status:   TxCommitted,
+				Sequence: tx.Sequence,
+			},
+			Transaction: tx,
+		}
+		if skipped {
+			status.Status = TxRejected
+			status.Reason = "committed but not applied: " + reason
+		}
+		return status, true
+	}
+
+	status, ok := a.Node.app.submissions.status(id)
+	if !ok {
+		return nil, false
+	}
+	return &TxStatusData{TxStatus: *status}, true
+}
+
+// TransactionStatus returns the status of the request with the UUID given in the path /api/tx/{id}
+func (a *APIServer) TransactionStatus(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/api/tx/")
+	if id == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := a.transactionStatus(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Transaction %v not found", id), http.StatusNotFound)
+		return
+	}
+
+	a.encodeJSON(w, status)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExplorerBlocks(t *testing.T) {
+	app := newTestApp(t, newTestCA(t), 1, 1)
+	api := &APIServer{Node: app.Node}
+
+	// Sequences 2, 4, ..., 60
+	for i := 1; i <= 30; i++ {
+		app.Node.cb.add(testRecord(t, uint64(2*i), testReservedRequest(i)))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		status int
+		first  uint64 // sequence of the first listed block
+		count  int
+	}{
+		{"latest", "", http.StatusOK, 22, explorerDefaultLimit},
+		{"latest with limit", "limit=5", http.StatusOK, 52, 5},
+		{"first page", "start=0&limit=5", http.StatusOK, 2, 5},
+		{"start between blocks", "start=3&limit=5", http.StatusOK, 4, 5},
+		{"last page", "start=56&limit=5", http.StatusOK, 56, 3},
+		{"after latest", "start=61", http.StatusOK, 0, 0},
+		{"limit above maximum", "start=0&limit=1000", http.StatusOK, 2, 30},
+		{"zero limit", "limit=0", http.StatusBadRequest, 0, 0},
+		{"invalid limit", "limit=x", http.StatusBadRequest, 0, 0},
+		{"negative start", "start=-1", http.StatusBadRequest, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			api.Blocks(recorder, httptest.NewRequest(http.MethodGet, "/api/blocks?"+tc.query, nil))
+			if recorder.Code != tc.status {
+				t.Fatalf("Status %d, expected %d", recorder.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			page := &BlockPage{}
+			if err := json.NewDecoder(recorder.Body).Decode(page); err != nil {
+				t.Fatal(err)
+			}
+			if page.LatestSequence != 60 {
+				t.Fatalf("Latest sequence %d, expected 60", page.LatestSequence)
+			}
+			if len(page.Blocks) != tc.count {
+				t.Fatalf("Listed %d blocks, expected %d", len(page.Blocks), tc.count)
+			}
+			for i, block := range page.Blocks {
+				if block.Sequence != tc.first+uint64(2*i) {
+					t.Fatalf("Block %d has sequence %d, expected %d", i, block.Sequence, tc.first+uint64(2*i))
+				}
+			}
+		})
+	}
+}
+
+func TestExplorerBlock(t *testing.T) {
+	app := newTestApp(t, newTestCA(t), 1, 1)
+	api := &APIServer{Node: app.Node}
+	app.Node.cb.add(testRecord(t, 2, testReservedRequest(1), testReservedRequest(2)))
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"existing block", "seq=2", http.StatusOK},
+		{"missing block", "seq=1", http.StatusNotFound},
+		{"after latest", "seq=3", http.StatusNotFound},
+		{"invalid sequence", "seq=x", http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			api.Block(recorder, httptest.NewRequest(http.MethodGet, "/api/block?"+tc.query, nil))
+			if recorder.Code != tc.status {
+				t.Fatalf("Status %d, expected %d", recorder.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			block := &BlockData{}
+			if err := json.NewDecoder(recorder.Body).Decode(block); err != nil {
+				t.Fatal(err)
+			}
+			if block.Sequence != 2 || len(block.Transactions) != 2 || block.Transactions[1].ID != "request-2" {
+				t.Fatalf("Unexpected block %+v", block)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package gcodesim
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"math"
+	"sort"
 
 	svg "github.com/ajstarks/svgo"
 )
@@ -99,3 +102,71 @@ func (l *Layer) SVG() []byte {
 	canvas.End()
 	return buf.Bytes()
 }
+
+// Summary describes the result of a simulated fabrication
+type Summary struct {
+	Layers           int // layers containing extrusion movements
+	ExtrudedFilament float64
+	Min              Position // bounding box of extrusion movements
+	Max              Position
+}
+
+func (p *Printer) Summary() Summary {
+	var s Summary
+	s.ExtrudedFilament = p.ExtrudedFilament
+
+	// Map iteration order is random, collect keys for deterministic results
+	var zs []float64
+	for z, l := range p.Layers {
+		if l.ExtrudeMovements > 0 {
+			zs = append(zs, z)
+		}
+	}
+	sort.Float64s(zs)
+	s.Layers = len(zs)
+
+	first := true
+	for _, z := range zs {
+		for _, l := range p.Layers[z].Lines {
+			for _, pos := range []Position{{X: l.x0, Y: l.y0, Z: z}, {X: l.x1, Y: l.y1, Z: z}} {
+				if first {
+					s.Min, s.Max = pos, pos
+					first = false
+					continue
+				}
+				s.Min.X, s.Max.X = math.Min(s.Min.X, pos.X), math.Max(s.Max.X, pos.X)
+				s.Min.Y, s.Max.Y = math.Min(s.Min.Y, pos.Y), math.Max(s.Max.Y, pos.Y)
+				s.Min.Z, s.Max.Z = math.Min(s.Min.Z, pos.Z), math.Max(s.Max.Z, pos.Z)
+			}
+		}
+	}
+
+	return s
+}
+
+// Simulate parses the G-code program from r and simulates it on a new printer
+func Simulate(r io.Reader) (*Printer, error) {
+	printer := NewPrinter()
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		instruction, err := ParseInstruction(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		if instruction == nil {
+			continue
+		}
+
+		err = instruction.InstructionCode.Simulate(printer, instruction.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", instruction.Format(), err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return printer, nil
+}
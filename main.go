@@ -3,9 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -15,8 +13,12 @@ import (
 )
 
 var (
-	selfID   *uint64
-	nodeName string
+	selfID    *uint64
+	dataDir   *string
+	tokenFile *string
+	httpsCert *string
+	httpsKey  *string
+	nodeName  string
 )
 
 type arrayFlags []string
@@ -35,9 +37,11 @@ var flagPeers arrayFlags
 func init() {
 	log.SetFlags(log.Lshortfile | log.LstdFlags)
 	selfID = flag.Uint64("id", 1, "id number")
+	dataDir = flag.String("data", "data", "directory for persistent ledger and WAL")
 	flag.Var(&flagPeers, "peers", "Set peers to add without discovery")
-	flag.Parse()
-	nodeName = "node" + strconv.FormatUint(*selfID, 10)
+	tokenFile = flag.String("tokens", "", "JSON file mapping SHA-256 hashes of API tokens to users and roles")
+	httpsCert = flag.String("https-cert", "", "certificate for the HTTPS API, defaults to the node certificate")
+	httpsKey = flag.String("https-key", "", "key for the HTTPS API certificate")
 }
 
 type TLSPaths struct {
@@ -47,14 +51,11 @@ type TLSPaths struct {
 }
 
 func main() {
-	log.Println("Starting with ID", selfID)
-
-	output, err := os.OpenFile("/tmp/"+strconv.FormatUint(*selfID, 10), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
-	if err != nil {
-		panic(err)
-	}
+	// Parsing in init would reject the flags of go test
+	flag.Parse()
+	nodeName = "node" + strconv.FormatUint(*selfID, 10)
 
-	tmpdir, _ := ioutil.TempDir("", "app-"+nodeName)
+	log.Println("Starting with ID", selfID)
 
 	tlsPaths := TLSPaths{
 		NodeCertificate: path.Join("res", "ca", nodeName+".crt"),
@@ -62,12 +63,23 @@ func main() {
 		CaCertificate:   path.Join("res", "ca", "ca.crt"),
 	}
 
-	node := newNode(NodeID(*selfID), tmpdir, tlsPaths, true, 10)
+	node := newNode(NodeID(*selfID), *dataDir, tlsPaths, true, 10)
 
 	// Allow for initial peer discovery..
 	time.Sleep(10 * time.Second)
 
-	err = node.Consensus.Start()
+	err := node.Consensus.Start()
+	if err != nil {
+		panic(err)
+	}
+
+	events := newEventBroker()
+
+	apiTLSConfig, err := loadAPITLSConfig(tlsPaths, *httpsCert, *httpsKey)
+	if err != nil {
+		panic(err)
+	}
+	apiTokens, err := loadTokens(*tokenFile)
 	if err != nil {
 		panic(err)
 	}
@@ -75,10 +87,26 @@ func main() {
 	apiSrv := APIServer{
 		Node:                node.Node,
 		FabricationEndpoint: "localhost:9001",
+		Events:              events,
+		TLSConfig:           apiTLSConfig,
+		Tokens:              apiTokens,
 	}
 
 	apiPort := 8000 + *selfID
-	go apiSrv.ServeHTTP(":" + strconv.Itoa(int(apiPort)))
+	go func() {
+		err := apiSrv.ServeHTTP(":" + strconv.Itoa(int(apiPort)))
+		if err != nil {
+			log.Println("HTTPS API stopped: ", err)
+		}
+	}()
+
+	clientAPIPort := 4000 + *selfID
+	go func() {
+		err := ServeClientAPI(node.Node, events, tlsPaths, ":"+strconv.Itoa(int(clientAPIPort)))
+		if err != nil {
+			log.Println("Client API stopped: ", err)
+		}
+	}()
 
 	go func() {
 		time.Sleep(10 * time.Second)
@@ -87,7 +115,10 @@ func main() {
 			if err != nil {
 				log.Fatal(err)
 			}
-			node.Submit(Request{ID: reqID.String(), ClientID: fmt.Sprintf("node-%v", *selfID)})
+			err = node.Submit(Request{ID: reqID.String(), ClientID: fmt.Sprintf("node-%v", *selfID)})
+			if err != nil {
+				log.Println(err)
+			}
 			time.Sleep(1 * time.Second)
 		}
 	}()
@@ -96,9 +127,9 @@ func main() {
 		node.Node.app.logger.Debug("Delivered message: ", delivery)
 		for _, v := range delivery.Batch.Requests {
 			req := requestFromBytes(v)
-			fmt.Fprintf(output, "%v - %v\n", req.ID, req.ClientID)
 			log.Printf("Received delivered Request ID %v from ClientID %v\n", req.ID, req.ClientID)
 		}
+		events.publish(delivery)
 	}
 
 }
@@ -11,6 +11,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
 	"net"
 	"sort"
@@ -21,9 +23,11 @@ import (
 
 	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
 	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"golang.org/x/crypto/sha3"
 	grpc "google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	grpcpeer "google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/proto"
 	anypb "google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -71,7 +75,7 @@ type Node struct {
 }
 
 // AddOrUpdateNode adds or updates a node in the network
-func StartNode(id NodeID, h handler, app *App, tlsPaths TLSPaths) (*Node, error) {
+func StartNode(id NodeID, h handler, app *App, cb *committedBatches, tlsPaths TLSPaths) (*Node, error) {
 	var err error
 	port := 3000 + int(id)
 
@@ -85,6 +89,7 @@ func StartNode(id NodeID, h handler, app *App, tlsPaths TLSPaths) (*Node, error)
 		nodeExchanges: make(map[NodeID]NodeExchangeClient),
 		id:            id,
 		app:           app,
+		cb:            cb,
 	}
 
 	node.transportCred, err = loadTLSCredentials(tlsPaths)
@@ -99,7 +104,6 @@ func StartNode(id NodeID, h handler, app *App, tlsPaths TLSPaths) (*Node, error)
 	}
 
 	node.peers[id] = selfPeer
-	node.cb = newCommittedBatches()
 	/*
 		node.discoverer = new(ListDiscoverer)
 		node.discoverer.Start(*selfPeer)
@@ -132,8 +136,8 @@ func StartNode(id NodeID, h handler, app *App, tlsPaths TLSPaths) (*Node, error)
 			if id == NodeID(leaderID) {
 				node.app.logger.Debug("Starting reconfig with Nodes: ", node.Nodes())
 
-				node.app.Submit(Request{
-					ClientID: "reconfig",
+				err := node.app.Submit(Request{
+					ClientID: reconfigClientID,
 					ID:       fmt.Sprintf("add_node-%v_%v", peer.PeerID, time.Now().Unix()),
 					Reconfig: Reconfig{
 						InLatestDecision: true,
@@ -141,6 +145,9 @@ func StartNode(id NodeID, h handler, app *App, tlsPaths TLSPaths) (*Node, error)
 						CurrentConfig:    recconfigToInt(types.Reconfig{CurrentConfig: app.Consensus.Config}).CurrentConfig,
 					},
 				})
+				if err != nil {
+					node.app.logger.Error("Error submitting reconfig: ", err)
+				}
 			}
 
 		}
@@ -347,6 +354,15 @@ func (n *nodeExchange) ConsensusMessage(ctx context.Context, msg *Consensus) (*e
 		return &emptypb.Empty{}, nil
 	}
 
+	// Consensus messages are attributed to the sending node, which must match the peer certificate
+	sender, err := peerNodeID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(sender) != msg.Node {
+		return nil, fmt.Errorf("node %d sent message as node %d", sender, msg.Node)
+	}
+
 	n.messageChannel <- *msg
 	return &emptypb.Empty{}, nil
 }
@@ -359,9 +375,22 @@ func (n *nodeExchange) FetchBlocks(pos *BlockPosition, stream NodeExchange_Fetch
 	})
 
 	for _, record := range records {
+		var signatures []*ConsenterSignature
+		for _, sig := range record.Signatures {
+			signatures = append(signatures, &ConsenterSignature{
+				Id:    sig.ID,
+				Value: sig.Value,
+				Msg:   sig.Msg,
+			})
+		}
+
 		err := stream.Send(&BlockRecord{
-			Metadata: record.Metadata,
-			Batch:    record.Batch.Requests,
+			Metadata:             record.Metadata,
+			Batch:                record.Batch.Requests,
+			PrevHash:             record.PrevHash,
+			Header:               record.Proposal.Header,
+			VerificationSequence: record.Proposal.VerificationSequence,
+			Signatures:           signatures,
 		})
 		if err != nil {
 			return err
@@ -371,6 +400,16 @@ func (n *nodeExchange) FetchBlocks(pos *BlockPosition, stream NodeExchange_Fetch
 	return nil
 }
 
+func (n *nodeExchange) BlockHeight(ctx context.Context, _ *emptypb.Empty) (*BlockPosition, error) {
+	n.committedBatches.lock.RLock()
+	defer n.committedBatches.lock.RUnlock()
+
+	return &BlockPosition{
+		ViewId:         n.committedBatches.latestMD.GetViewId(),
+		LatestSequence: n.committedBatches.latestMD.GetLatestSequence(),
+	}, nil
+}
+
 func (n *nodeExchange) DownloadContent(id *ContentID, stream NodeExchange_DownloadContentServer) error {
 
 	var address FabricationDataHash
@@ -381,21 +420,104 @@ func (n *nodeExchange) DownloadContent(id *ContentID, stream NodeExchange_Downlo
 
 	copy(address[:], id.Id)
 
-	content, err := n.store.GetData(address)
+	content, err := n.store.OpenData(address)
 	if err != nil {
 		return err
 	}
+	defer content.Close()
 
 	// Send file in 1MiB chunks, default GRPC limit is ~4M(i)B?
-	for _, chunk := range splitByteSlice(content, 1024*1024) {
-		err := stream.Send(&ContentChunk{
-			Chunk: chunk,
-		})
+	buf := make([]byte, 1024*1024)
+	for {
+		read, err := io.ReadFull(content, buf)
+		if read > 0 {
+			sendErr := stream.Send(&ContentChunk{
+				Chunk: buf[:read],
+			})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 	}
+}
+
+// downloadContent streams the stored data with the given address from the first connected node holding it.
+// The content hash is verified once the stream is read to the end.
+func (n *Node) downloadContent(address FabricationDataHash) (io.ReadCloser, error) {
+	n.Lock()
+	clients := make([]NodeExchangeClient, 0, len(n.nodeExchanges))
+	for _, client := range n.nodeExchanges {
+		clients = append(clients, client)
+	}
+	n.Unlock()
+
+	for _, client := range clients {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := client.DownloadContent(ctx, &ContentID{Id: address[:]})
+		if err != nil {
+			cancel()
+			continue
+		}
+
+		// Nodes without the data fail the stream before the first chunk
+		first, err := stream.Recv()
+		if err != nil {
+			cancel()
+			continue
+		}
+
+		return &contentReader{
+			stream:  stream,
+			buf:     first.Chunk,
+			cancel:  cancel,
+			hash:    sha3.New512(),
+			address: address,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("content %x not available from any node", address)
+}
 
+// contentReader reads a DownloadContent stream, verifying the content hash at the end
+type contentReader struct {
+	stream  NodeExchange_DownloadContentClient
+	buf     []byte
+	cancel  context.CancelFunc
+	hash    hash.Hash
+	address FabricationDataHash
+}
+
+func (c *contentReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		chunk, err := c.stream.Recv()
+		if err == io.EOF {
+			var received FabricationDataHash
+			copy(received[:], c.hash.Sum(nil))
+			if received != c.address {
+				return 0, errors.New("downloaded content does not match hash")
+			}
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		c.buf = chunk.Chunk
+	}
+
+	read := copy(p, c.buf)
+	c.hash.Write(c.buf[:read])
+	c.buf = c.buf[read:]
+	return read, nil
+}
+
+func (c *contentReader) Close() error {
+	c.cancel()
 	return nil
 }
 
@@ -421,25 +543,49 @@ func loadTLSCredentials(paths TLSPaths) (credentials.TransportCredentials, error
 
 	// Create the credentials and return it
 	config := &tls.Config{
-		RootCAs:      pool,
-		ClientCAs:    pool,
-		Certificates: []tls.Certificate{serverCert},
-		ClientAuth:   tls.RequireAndVerifyClientCert, // only verified P2P Connections
+		RootCAs:               pool,
+		ClientCAs:             pool,
+		Certificates:          []tls.Certificate{serverCert},
+		ClientAuth:            tls.RequireAndVerifyClientCert, // only verified P2P Connections
+		VerifyPeerCertificate: verifyNodeCertificate,
 	}
 
 	return credentials.NewTLS(config), nil
 }
 
-// https://gist.github.com/xlab/6e204ef96b4433a697b3
-func splitByteSlice(buf []byte, lim int) [][]byte {
-	var chunk []byte
-	chunks := make([][]byte, 0, len(buf)/lim+1)
-	for len(buf) >= lim {
-		chunk, buf = buf[:lim], buf[lim:]
-		chunks = append(chunks, chunk)
+// verifyNodeCertificate only accepts peers presenting a node certificate,
+// user certificates issued by the same CA are restricted to the client APIs
+func verifyNodeCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		if _, err := nodeIDFromCertificate(chain[0]); err == nil {
+			return nil
+		}
+	}
+	return errors.New("peer certificate is not a node certificate")
+}
+
+// nodeIDFromCertificate returns the node identified by a certificate with common name node<id>
+// TODO standardize CN formatting
+func nodeIDFromCertificate(cert *x509.Certificate) (NodeID, error) {
+	if len(cert.Subject.OrganizationalUnit) > 0 || !strings.HasPrefix(cert.Subject.CommonName, "node") {
+		return 0, fmt.Errorf("%q is not a node certificate", cert.Subject.CommonName)
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(cert.Subject.CommonName, "node"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a node certificate", cert.Subject.CommonName)
+	}
+	return NodeID(id), nil
+}
+
+// peerNodeID returns the node authenticated by the TLS connection of the request
+func peerNodeID(ctx context.Context) (NodeID, error) {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return 0, errors.New("unauthenticated peer")
 	}
-	if len(buf) > 0 {
-		chunks = append(chunks, buf[:len(buf)])
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return 0, errors.New("unauthenticated peer")
 	}
-	return chunks
+	return nodeIDFromCertificate(tlsInfo.State.VerifiedChains[0][0])
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/grpc"
+)
+
+// testContentStream replays chunks followed by the given error
+type testContentStream struct {
+	grpc.ClientStream
+	chunks [][]byte
+	err    error
+}
+
+func (s *testContentStream) Recv() (*ContentChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, s.err
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &ContentChunk{Chunk: chunk}, nil
+}
+
+func TestContentReader(t *testing.T) {
+	content := bytes.Repeat([]byte("ciphertext"), 1000)
+	address := FabricationDataHash(sha3.Sum512(content))
+	chunks := [][]byte{content[:3000], content[3000:7000], content[7000:]}
+	streamErr := errors.New("stream reset")
+
+	for _, tc := range []struct {
+		name    string
+		chunks  [][]byte
+		err     error
+		address FabricationDataHash
+		valid   bool
+	}{
+		{"complete", chunks, io.EOF, address, true},
+		{"other content", chunks, io.EOF, FabricationDataHash{1}, false},
+		{"missing chunk", [][]byte{chunks[0], chunks[2]}, io.EOF, address, false},
+		{"stream error", chunks[:2], streamErr, address, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cancelled bool
+			reader := &contentReader{
+				stream:  &testContentStream{chunks: tc.chunks, err: tc.err},
+				cancel:  func() { cancelled = true },
+				hash:    sha3.New512(),
+				address: tc.address,
+			}
+
+			received, err := io.ReadAll(reader)
+			if tc.valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(received, content) {
+					t.Fatal("Received content differs")
+				}
+			} else if err == nil {
+				t.Fatal("Accepted invalid content")
+			}
+
+			reader.Close()
+			if !cancelled {
+				t.Fatal("Close did not cancel the stream")
+			}
+		})
+	}
+}
@@ -181,9 +181,12 @@ type BlockRecord struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Metadata []byte   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"` //opaque view metadata
-	Batch    [][]byte `protobuf:"bytes,2,rep,name=batch,proto3" json:"batch,omitempty"`
-	PrevHash []byte   `protobuf:"bytes,3,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
+	Metadata             []byte                `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"` //opaque view metadata
+	Batch                [][]byte              `protobuf:"bytes,2,rep,name=batch,proto3" json:"batch,omitempty"`
+	PrevHash             []byte                `protobuf:"bytes,3,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
+	Header               []byte                `protobuf:"bytes,4,opt,name=header,proto3" json:"header,omitempty"`
+	VerificationSequence int64                 `protobuf:"varint,5,opt,name=verificationSequence,proto3" json:"verificationSequence,omitempty"`
+	Signatures           []*ConsenterSignature `protobuf:"bytes,6,rep,name=signatures,proto3" json:"signatures,omitempty"`
 }
 
 func (x *BlockRecord) Reset() {
@@ -239,6 +242,90 @@ func (x *BlockRecord) GetPrevHash() []byte {
 	return nil
 }
 
+func (x *BlockRecord) GetHeader() []byte {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *BlockRecord) GetVerificationSequence() int64 {
+	if x != nil {
+		return x.VerificationSequence
+	}
+	return 0
+}
+
+func (x *BlockRecord) GetSignatures() []*ConsenterSignature {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+type ConsenterSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Msg   []byte `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+}
+
+func (x *ConsenterSignature) Reset() {
+	*x = ConsenterSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConsenterSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsenterSignature) ProtoMessage() {}
+
+func (x *ConsenterSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsenterSignature.ProtoReflect.Descriptor instead.
+func (*ConsenterSignature) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConsenterSignature) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ConsenterSignature) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ConsenterSignature) GetMsg() []byte {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
 type FwdMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -251,7 +338,7 @@ type FwdMessage struct {
 func (x *FwdMessage) Reset() {
 	*x = FwdMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_node_messages_proto_msgTypes[4]
+		mi := &file_node_messages_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -264,7 +351,7 @@ func (x *FwdMessage) String() string {
 func (*FwdMessage) ProtoMessage() {}
 
 func (x *FwdMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_node_messages_proto_msgTypes[4]
+	mi := &file_node_messages_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -277,7 +364,7 @@ func (x *FwdMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FwdMessage.ProtoReflect.Descriptor instead.
 func (*FwdMessage) Descriptor() ([]byte, []int) {
-	return file_node_messages_proto_rawDescGZIP(), []int{4}
+	return file_node_messages_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *FwdMessage) GetSender() uint64 {
@@ -306,7 +393,7 @@ type Consensus struct {
 func (x *Consensus) Reset() {
 	*x = Consensus{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_node_messages_proto_msgTypes[5]
+		mi := &file_node_messages_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -319,7 +406,7 @@ func (x *Consensus) String() string {
 func (*Consensus) ProtoMessage() {}
 
 func (x *Consensus) ProtoReflect() protoreflect.Message {
-	mi := &file_node_messages_proto_msgTypes[5]
+	mi := &file_node_messages_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -332,23 +419,1696 @@ func (x *Consensus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Consensus.ProtoReflect.Descriptor instead.
 func (*Consensus) Descriptor() ([]byte, []int) {
-	return file_node_messages_proto_rawDescGZIP(), []int{5}
+	return file_node_messages_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Consensus) GetNode() uint64 {
+	if x != nil {
+		return x.Node
+	}
+	return 0
+}
+
+func (x *Consensus) GetMessage() *anypb.Any {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+// Versioned payload of a Request, the set field has to match the request type
+type TransactionPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Types that are assignable to Payload:
+	//	*TransactionPayload_AddFile
+	//	*TransactionPayload_AllowFabrication
+	//	*TransactionPayload_AnnounceFabrication
+	//	*TransactionPayload_CancelFabrication
+	//	*TransactionPayload_FabricationCompleted
+	//	*TransactionPayload_RevokeFabrication
+	//	*TransactionPayload_AdjustFabrication
+	//	*TransactionPayload_TransferFabrication
+	Payload isTransactionPayload_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *TransactionPayload) Reset() {
+	*x = TransactionPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransactionPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionPayload) ProtoMessage() {}
+
+func (x *TransactionPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionPayload.ProtoReflect.Descriptor instead.
+func (*TransactionPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TransactionPayload) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (m *TransactionPayload) GetPayload() isTransactionPayload_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetAddFile() *AddFilePayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_AddFile); ok {
+		return x.AddFile
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetAllowFabrication() *AllowFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_AllowFabrication); ok {
+		return x.AllowFabrication
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetAnnounceFabrication() *AnnounceFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_AnnounceFabrication); ok {
+		return x.AnnounceFabrication
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetCancelFabrication() *CancelFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_CancelFabrication); ok {
+		return x.CancelFabrication
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetFabricationCompleted() *FabricationCompletedPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_FabricationCompleted); ok {
+		return x.FabricationCompleted
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetRevokeFabrication() *RevokeFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_RevokeFabrication); ok {
+		return x.RevokeFabrication
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetAdjustFabrication() *AdjustFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_AdjustFabrication); ok {
+		return x.AdjustFabrication
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetTransferFabrication() *TransferFabricationPayload {
+	if x, ok := x.GetPayload().(*TransactionPayload_TransferFabrication); ok {
+		return x.TransferFabrication
+	}
+	return nil
+}
+
+type isTransactionPayload_Payload interface {
+	isTransactionPayload_Payload()
+}
+
+type TransactionPayload_AddFile struct {
+	AddFile *AddFilePayload `protobuf:"bytes,2,opt,name=addFile,proto3,oneof"`
+}
+
+type TransactionPayload_AllowFabrication struct {
+	AllowFabrication *AllowFabricationPayload `protobuf:"bytes,3,opt,name=allowFabrication,proto3,oneof"`
+}
+
+type TransactionPayload_AnnounceFabrication struct {
+	AnnounceFabrication *AnnounceFabricationPayload `protobuf:"bytes,4,opt,name=announceFabrication,proto3,oneof"`
+}
+
+type TransactionPayload_CancelFabrication struct {
+	CancelFabrication *CancelFabricationPayload `protobuf:"bytes,5,opt,name=cancelFabrication,proto3,oneof"`
+}
+
+type TransactionPayload_FabricationCompleted struct {
+	FabricationCompleted *FabricationCompletedPayload `protobuf:"bytes,6,opt,name=fabricationCompleted,proto3,oneof"`
+}
+
+type TransactionPayload_RevokeFabrication struct {
+	RevokeFabrication *RevokeFabricationPayload `protobuf:"bytes,7,opt,name=revokeFabrication,proto3,oneof"`
+}
+
+type TransactionPayload_AdjustFabrication struct {
+	AdjustFabrication *AdjustFabricationPayload `protobuf:"bytes,8,opt,name=adjustFabrication,proto3,oneof"`
+}
+
+type TransactionPayload_TransferFabrication struct {
+	TransferFabrication *TransferFabricationPayload `protobuf:"bytes,9,opt,name=transferFabrication,proto3,oneof"`
+}
+
+func (*TransactionPayload_AddFile) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_AllowFabrication) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_AnnounceFabrication) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_CancelFabrication) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_FabricationCompleted) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_RevokeFabrication) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_AdjustFabrication) isTransactionPayload_Payload() {}
+
+func (*TransactionPayload_TransferFabrication) isTransactionPayload_Payload() {}
+
+// Fabrication data is stored encrypted with a random file key, the hash addresses the ciphertext.
+// The file key is encrypted to each node allowed to decrypt it using ECIES with the node certificate key.
+type AddFilePayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash   []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	OriginNode uint64 `protobuf:"varint,2,opt,name=originNode,proto3" json:"originNode,omitempty"`
+	WrappedKey []byte `protobuf:"bytes,3,opt,name=wrappedKey,proto3" json:"wrappedKey,omitempty"` // file key encrypted to the originating node
+}
+
+func (x *AddFilePayload) Reset() {
+	*x = AddFilePayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddFilePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddFilePayload) ProtoMessage() {}
+
+func (x *AddFilePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddFilePayload.ProtoReflect.Descriptor instead.
+func (*AddFilePayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AddFilePayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *AddFilePayload) GetOriginNode() uint64 {
+	if x != nil {
+		return x.OriginNode
+	}
+	return 0
+}
+
+func (x *AddFilePayload) GetWrappedKey() []byte {
+	if x != nil {
+		return x.WrappedKey
+	}
+	return nil
+}
+
+type AllowFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash    []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AllowedNode uint64 `protobuf:"varint,2,opt,name=allowedNode,proto3" json:"allowedNode,omitempty"`
+	Count       uint32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`           // maximum parts
+	ValidFrom   int64  `protobuf:"varint,4,opt,name=validFrom,proto3" json:"validFrom,omitempty"`   // Unix seconds in ledger time, 0 is unbounded
+	ValidUntil  int64  `protobuf:"varint,5,opt,name=validUntil,proto3" json:"validUntil,omitempty"` // exclusive
+	WrappedKey  []byte `protobuf:"bytes,6,opt,name=wrappedKey,proto3" json:"wrappedKey,omitempty"`  // file key encrypted to the allowed node
+}
+
+func (x *AllowFabricationPayload) Reset() {
+	*x = AllowFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AllowFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AllowFabricationPayload) ProtoMessage() {}
+
+func (x *AllowFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AllowFabricationPayload.ProtoReflect.Descriptor instead.
+func (*AllowFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AllowFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *AllowFabricationPayload) GetAllowedNode() uint64 {
+	if x != nil {
+		return x.AllowedNode
+	}
+	return 0
+}
+
+func (x *AllowFabricationPayload) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AllowFabricationPayload) GetValidFrom() int64 {
+	if x != nil {
+		return x.ValidFrom
+	}
+	return 0
+}
+
+func (x *AllowFabricationPayload) GetValidUntil() int64 {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return 0
+}
+
+func (x *AllowFabricationPayload) GetWrappedKey() []byte {
+	if x != nil {
+		return x.WrappedKey
+	}
+	return nil
+}
+
+// Announcing and cancelling protects against network / power glitches to prevent production of excess parts.
+// The request ID must be a UUID, cancellations reference it.
+type AnnounceFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	Count    uint32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"` // parts intended to produce
+}
+
+func (x *AnnounceFabricationPayload) Reset() {
+	*x = AnnounceFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnnounceFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnounceFabricationPayload) ProtoMessage() {}
+
+func (x *AnnounceFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnounceFabricationPayload.ProtoReflect.Descriptor instead.
+func (*AnnounceFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AnnounceFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *AnnounceFabricationPayload) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type CancelFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash    []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AnnounceId  string `protobuf:"bytes,2,opt,name=announceId,proto3" json:"announceId,omitempty"`
+	NotProduced uint32 `protobuf:"varint,3,opt,name=notProduced,proto3" json:"notProduced,omitempty"`
+}
+
+func (x *CancelFabricationPayload) Reset() {
+	*x = CancelFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CancelFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelFabricationPayload) ProtoMessage() {}
+
+func (x *CancelFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelFabricationPayload.ProtoReflect.Descriptor instead.
+func (*CancelFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CancelFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *CancelFabricationPayload) GetAnnounceId() string {
+	if x != nil {
+		return x.AnnounceId
+	}
+	return ""
+}
+
+func (x *CancelFabricationPayload) GetNotProduced() uint32 {
+	if x != nil {
+		return x.NotProduced
+	}
+	return 0
+}
+
+// Written by the fabricating node after the job finished
+type FabricationCompletedPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash     []byte                    `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AnnounceId   string                    `protobuf:"bytes,2,opt,name=announceId,proto3" json:"announceId,omitempty"`
+	Success      bool                      `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	CommandsSent int64                     `protobuf:"varint,4,opt,name=commandsSent,proto3" json:"commandsSent,omitempty"`
+	Simulation   *SimulationSummaryPayload `protobuf:"bytes,5,opt,name=simulation,proto3" json:"simulation,omitempty"`
+	Digest       []byte                    `protobuf:"bytes,6,opt,name=digest,proto3" json:"digest,omitempty"` // SHA3-256 of the ASN.1 encoded simulation summary
+}
+
+func (x *FabricationCompletedPayload) Reset() {
+	*x = FabricationCompletedPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FabricationCompletedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FabricationCompletedPayload) ProtoMessage() {}
+
+func (x *FabricationCompletedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FabricationCompletedPayload.ProtoReflect.Descriptor instead.
+func (*FabricationCompletedPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FabricationCompletedPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *FabricationCompletedPayload) GetAnnounceId() string {
+	if x != nil {
+		return x.AnnounceId
+	}
+	return ""
+}
+
+func (x *FabricationCompletedPayload) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *FabricationCompletedPayload) GetCommandsSent() int64 {
+	if x != nil {
+		return x.CommandsSent
+	}
+	return 0
+}
+
+func (x *FabricationCompletedPayload) GetSimulation() *SimulationSummaryPayload {
+	if x != nil {
+		return x.Simulation
+	}
+	return nil
+}
+
+func (x *FabricationCompletedPayload) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+// Lengths in micrometres
+type SimulationSummaryPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Simulated        bool  `protobuf:"varint,1,opt,name=simulated,proto3" json:"simulated,omitempty"`
+	Layers           int64 `protobuf:"varint,2,opt,name=layers,proto3" json:"layers,omitempty"`
+	ExtrudedFilament int64 `protobuf:"varint,3,opt,name=extrudedFilament,proto3" json:"extrudedFilament,omitempty"`
+	MinX             int64 `protobuf:"varint,4,opt,name=minX,proto3" json:"minX,omitempty"`
+	MinY             int64 `protobuf:"varint,5,opt,name=minY,proto3" json:"minY,omitempty"`
+	MinZ             int64 `protobuf:"varint,6,opt,name=minZ,proto3" json:"minZ,omitempty"`
+	MaxX             int64 `protobuf:"varint,7,opt,name=maxX,proto3" json:"maxX,omitempty"`
+	MaxY             int64 `protobuf:"varint,8,opt,name=maxY,proto3" json:"maxY,omitempty"`
+	MaxZ             int64 `protobuf:"varint,9,opt,name=maxZ,proto3" json:"maxZ,omitempty"`
+}
+
+func (x *SimulationSummaryPayload) Reset() {
+	*x = SimulationSummaryPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SimulationSummaryPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulationSummaryPayload) ProtoMessage() {}
+
+func (x *SimulationSummaryPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulationSummaryPayload.ProtoReflect.Descriptor instead.
+func (*SimulationSummaryPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SimulationSummaryPayload) GetSimulated() bool {
+	if x != nil {
+		return x.Simulated
+	}
+	return false
+}
+
+func (x *SimulationSummaryPayload) GetLayers() int64 {
+	if x != nil {
+		return x.Layers
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetExtrudedFilament() int64 {
+	if x != nil {
+		return x.ExtrudedFilament
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMinX() int64 {
+	if x != nil {
+		return x.MinX
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMinY() int64 {
+	if x != nil {
+		return x.MinY
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMinZ() int64 {
+	if x != nil {
+		return x.MinZ
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMaxX() int64 {
+	if x != nil {
+		return x.MaxX
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMaxY() int64 {
+	if x != nil {
+		return x.MaxY
+	}
+	return 0
+}
+
+func (x *SimulationSummaryPayload) GetMaxZ() int64 {
+	if x != nil {
+		return x.MaxZ
+	}
+	return 0
+}
+
+type RevokeFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash    []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AllowedNode uint64 `protobuf:"varint,2,opt,name=allowedNode,proto3" json:"allowedNode,omitempty"`
+}
+
+func (x *RevokeFabricationPayload) Reset() {
+	*x = RevokeFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeFabricationPayload) ProtoMessage() {}
+
+func (x *RevokeFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeFabricationPayload.ProtoReflect.Descriptor instead.
+func (*RevokeFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RevokeFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *RevokeFabricationPayload) GetAllowedNode() uint64 {
+	if x != nil {
+		return x.AllowedNode
+	}
+	return 0
+}
+
+type AdjustFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash    []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AllowedNode uint64 `protobuf:"varint,2,opt,name=allowedNode,proto3" json:"allowedNode,omitempty"`
+	Remaining   uint32 `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+}
+
+func (x *AdjustFabricationPayload) Reset() {
+	*x = AdjustFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdjustFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustFabricationPayload) ProtoMessage() {}
+
+func (x *AdjustFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustFabricationPayload.ProtoReflect.Descriptor instead.
+func (*AdjustFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AdjustFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *AdjustFabricationPayload) GetAllowedNode() uint64 {
+	if x != nil {
+		return x.AllowedNode
+	}
+	return 0
+}
+
+func (x *AdjustFabricationPayload) GetRemaining() uint32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+type TransferFabricationPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash      []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	ReceivingNode uint64 `protobuf:"varint,2,opt,name=receivingNode,proto3" json:"receivingNode,omitempty"`
+	Count         uint32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	WrappedKey    []byte `protobuf:"bytes,4,opt,name=wrappedKey,proto3" json:"wrappedKey,omitempty"` // file key encrypted to the receiving node
+}
+
+func (x *TransferFabricationPayload) Reset() {
+	*x = TransferFabricationPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransferFabricationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferFabricationPayload) ProtoMessage() {}
+
+func (x *TransferFabricationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferFabricationPayload.ProtoReflect.Descriptor instead.
+func (*TransferFabricationPayload) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TransferFabricationPayload) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *TransferFabricationPayload) GetReceivingNode() uint64 {
+	if x != nil {
+		return x.ReceivingNode
+	}
+	return 0
+}
+
+func (x *TransferFabricationPayload) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TransferFabricationPayload) GetWrappedKey() []byte {
+	if x != nil {
+		return x.WrappedKey
+	}
+	return nil
+}
+
+// The first message of a SubmitFile stream carries the metadata, all following ones chunks of the content
+type SubmitFileRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Part:
+	//	*SubmitFileRequest_Metadata
+	//	*SubmitFileRequest_Chunk
+	Part isSubmitFileRequest_Part `protobuf_oneof:"part"`
+}
+
+func (x *SubmitFileRequest) Reset() {
+	*x = SubmitFileRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitFileRequest) ProtoMessage() {}
+
+func (x *SubmitFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitFileRequest.ProtoReflect.Descriptor instead.
+func (*SubmitFileRequest) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{17}
+}
+
+func (m *SubmitFileRequest) GetPart() isSubmitFileRequest_Part {
+	if m != nil {
+		return m.Part
+	}
+	return nil
+}
+
+func (x *SubmitFileRequest) GetMetadata() *SubmitFileMetadata {
+	if x, ok := x.GetPart().(*SubmitFileRequest_Metadata); ok {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *SubmitFileRequest) GetChunk() []byte {
+	if x, ok := x.GetPart().(*SubmitFileRequest_Chunk); ok {
+		return x.Chunk
+	}
+	return nil
+}
+
+type isSubmitFileRequest_Part interface {
+	isSubmitFileRequest_Part()
+}
+
+type SubmitFileRequest_Metadata struct {
+	Metadata *SubmitFileMetadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"`
+}
+
+type SubmitFileRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*SubmitFileRequest_Metadata) isSubmitFileRequest_Part() {}
+
+func (*SubmitFileRequest_Chunk) isSubmitFileRequest_Part() {}
+
+type SubmitFileMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AllowedNodes []uint64 `protobuf:"varint,1,rep,packed,name=allowedNodes,proto3" json:"allowedNodes,omitempty"`
+	Count        uint32   `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`           // parts per allowed node
+	ValidFrom    int64    `protobuf:"varint,3,opt,name=validFrom,proto3" json:"validFrom,omitempty"`   // Unix seconds in ledger time, 0 is unbounded
+	ValidUntil   int64    `protobuf:"varint,4,opt,name=validUntil,proto3" json:"validUntil,omitempty"` // exclusive
+}
+
+func (x *SubmitFileMetadata) Reset() {
+	*x = SubmitFileMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitFileMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitFileMetadata) ProtoMessage() {}
+
+func (x *SubmitFileMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitFileMetadata.ProtoReflect.Descriptor instead.
+func (*SubmitFileMetadata) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubmitFileMetadata) GetAllowedNodes() []uint64 {
+	if x != nil {
+		return x.AllowedNodes
+	}
+	return nil
+}
+
+func (x *SubmitFileMetadata) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SubmitFileMetadata) GetValidFrom() int64 {
+	if x != nil {
+		return x.ValidFrom
+	}
+	return 0
+}
+
+func (x *SubmitFileMetadata) GetValidUntil() int64 {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return 0
+}
+
+type SubmitFileResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash   []byte   `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	RequestIds []string `protobuf:"bytes,2,rep,name=requestIds,proto3" json:"requestIds,omitempty"`
+}
+
+func (x *SubmitFileResponse) Reset() {
+	*x = SubmitFileResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitFileResponse) ProtoMessage() {}
+
+func (x *SubmitFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitFileResponse.ProtoReflect.Descriptor instead.
+func (*SubmitFileResponse) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SubmitFileResponse) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *SubmitFileResponse) GetRequestIds() []string {
+	if x != nil {
+		return x.RequestIds
+	}
+	return nil
+}
+
+type GrantFabricationRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash    []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	AllowedNode uint64 `protobuf:"varint,2,opt,name=allowedNode,proto3" json:"allowedNode,omitempty"`
+	Count       uint32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	ValidFrom   int64  `protobuf:"varint,4,opt,name=validFrom,proto3" json:"validFrom,omitempty"`
+	ValidUntil  int64  `protobuf:"varint,5,opt,name=validUntil,proto3" json:"validUntil,omitempty"`
+}
+
+func (x *GrantFabricationRequest) Reset() {
+	*x = GrantFabricationRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GrantFabricationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantFabricationRequest) ProtoMessage() {}
+
+func (x *GrantFabricationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantFabricationRequest.ProtoReflect.Descriptor instead.
+func (*GrantFabricationRequest) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GrantFabricationRequest) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *GrantFabricationRequest) GetAllowedNode() uint64 {
+	if x != nil {
+		return x.AllowedNode
+	}
+	return 0
+}
+
+func (x *GrantFabricationRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GrantFabricationRequest) GetValidFrom() int64 {
+	if x != nil {
+		return x.ValidFrom
+	}
+	return 0
+}
+
+func (x *GrantFabricationRequest) GetValidUntil() int64 {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return 0
+}
+
+type SubmitResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=requestId,proto3" json:"requestId,omitempty"`
+}
+
+func (x *SubmitResponse) Reset() {
+	*x = SubmitResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitResponse) ProtoMessage() {}
+
+func (x *SubmitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitResponse.ProtoReflect.Descriptor instead.
+func (*SubmitResponse) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SubmitResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type Allowance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileHash        []byte `protobuf:"bytes,1,opt,name=fileHash,proto3" json:"fileHash,omitempty"`
+	OriginatingNode uint64 `protobuf:"varint,2,opt,name=originatingNode,proto3" json:"originatingNode,omitempty"`
+	Remaining       uint32 `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Pending         uint32 `protobuf:"varint,4,opt,name=pending,proto3" json:"pending,omitempty"`
+	Consumed        uint32 `protobuf:"varint,5,opt,name=consumed,proto3" json:"consumed,omitempty"`
+	Revoked         bool   `protobuf:"varint,6,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	ValidFrom       int64  `protobuf:"varint,7,opt,name=validFrom,proto3" json:"validFrom,omitempty"`
+	ValidUntil      int64  `protobuf:"varint,8,opt,name=validUntil,proto3" json:"validUntil,omitempty"`
+}
+
+func (x *Allowance) Reset() {
+	*x = Allowance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Allowance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allowance) ProtoMessage() {}
+
+func (x *Allowance) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allowance.ProtoReflect.Descriptor instead.
+func (*Allowance) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Allowance) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *Allowance) GetOriginatingNode() uint64 {
+	if x != nil {
+		return x.OriginatingNode
+	}
+	return 0
+}
+
+func (x *Allowance) GetRemaining() uint32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *Allowance) GetPending() uint32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *Allowance) GetConsumed() uint32 {
+	if x != nil {
+		return x.Consumed
+	}
+	return 0
+}
+
+func (x *Allowance) GetRevoked() bool {
+	if x != nil {
+		return x.Revoked
+	}
+	return false
+}
+
+func (x *Allowance) GetValidFrom() int64 {
+	if x != nil {
+		return x.ValidFrom
+	}
+	return 0
+}
+
+func (x *Allowance) GetValidUntil() int64 {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return 0
+}
+
+type AvailableList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Allowances []*Allowance `protobuf:"bytes,1,rep,name=allowances,proto3" json:"allowances,omitempty"`
+}
+
+func (x *AvailableList) Reset() {
+	*x = AvailableList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AvailableList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailableList) ProtoMessage() {}
+
+func (x *AvailableList) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailableList.ProtoReflect.Descriptor instead.
+func (*AvailableList) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AvailableList) GetAllowances() []*Allowance {
+	if x != nil {
+		return x.Allowances
+	}
+	return nil
+}
+
+type GetBlockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"` // 0 for the latest block
+}
+
+func (x *GetBlockRequest) Reset() {
+	*x = GetBlockRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlockRequest) ProtoMessage() {}
+
+func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlockRequest.ProtoReflect.Descriptor instead.
+func (*GetBlockRequest) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetBlockRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type Block struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence     uint64                `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	ViewId       uint64                `protobuf:"varint,2,opt,name=viewId,proto3" json:"viewId,omitempty"`
+	Hash         []byte                `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	PrevHash     []byte                `protobuf:"bytes,4,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
+	Timestamp    int64                 `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // ledger time, 0 for blocks without header
+	Transactions []*Transaction        `protobuf:"bytes,6,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Signatures   []*ConsenterSignature `protobuf:"bytes,7,rep,name=signatures,proto3" json:"signatures,omitempty"`
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Block) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Block) GetViewId() uint64 {
+	if x != nil {
+		return x.ViewId
+	}
+	return 0
+}
+
+func (x *Block) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *Block) GetPrevHash() []byte {
+	if x != nil {
+		return x.PrevHash
+	}
+	return nil
+}
+
+func (x *Block) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Block) GetSignatures() []*ConsenterSignature {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       string              `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ClientId string              `protobuf:"bytes,2,opt,name=clientId,proto3" json:"clientId,omitempty"`
+	Type     string              `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Payload  *TransactionPayload `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"` // unset for system requests
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Transaction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Transaction) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Transaction) GetPayload() *TransactionPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Types []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"` // event types to receive, all if empty
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *WatchEventsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Types that are assignable to Event:
+	//	*Event_Block
+	//	*Event_Transaction
+	Event isEvent_Event `protobuf_oneof:"event"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_node_messages_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_node_messages_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_node_messages_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
 }
 
-func (x *Consensus) GetNode() uint64 {
+func (x *Event) GetSequence() uint64 {
 	if x != nil {
-		return x.Node
+		return x.Sequence
 	}
 	return 0
 }
 
-func (x *Consensus) GetMessage() *anypb.Any {
-	if x != nil {
-		return x.Message
+func (m *Event) GetEvent() isEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *Event) GetBlock() *Block {
+	if x, ok := x.GetEvent().(*Event_Block); ok {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *Event) GetTransaction() *Transaction {
+	if x, ok := x.GetEvent().(*Event_Transaction); ok {
+		return x.Transaction
 	}
 	return nil
 }
 
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_Block struct {
+	Block *Block `protobuf:"bytes,3,opt,name=block,proto3,oneof"` // without transactions and signatures
+}
+
+type Event_Transaction struct {
+	Transaction *Transaction `protobuf:"bytes,4,opt,name=transaction,proto3,oneof"`
+}
+
+func (*Event_Block) isEvent_Event() {}
+
+func (*Event_Transaction) isEvent_Event() {}
+
 var File_node_messages_proto protoreflect.FileDescriptor
 
 var file_node_messages_proto_rawDesc = []byte{
@@ -366,35 +2126,301 @@ var file_node_messages_proto_rawDesc = []byte{
 	0x65, 0x77, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x76, 0x69, 0x65, 0x77,
 	0x49, 0x64, 0x12, 0x26, 0x0a, 0x0e, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x53, 0x65, 0x71, 0x75,
 	0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x6c, 0x61, 0x74, 0x65,
-	0x73, 0x74, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x5b, 0x0a, 0x0b, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x65, 0x74,
-	0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6d, 0x65, 0x74,
-	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18, 0x02,
-	0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x70,
-	0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70,
-	0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x22, 0x3e, 0x0a, 0x0a, 0x46, 0x77, 0x64, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x18, 0x0a,
-	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
-	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x4f, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x73, 0x65,
-	0x6e, 0x73, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52,
-	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x32, 0xd3, 0x01, 0x0a, 0x0c, 0x4e, 0x6f, 0x64,
-	0x65, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x40, 0x0a, 0x10, 0x43, 0x6f, 0x6e,
-	0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x2e,
-	0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75,
-	0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x0b, 0x46,
-	0x65, 0x74, 0x63, 0x68, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x61, 0x62,
-	0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x1a, 0x14, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c, 0x6f,
-	0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x00, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x0f,
-	0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12,
-	0x12, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
-	0x74, 0x49, 0x44, 0x1a, 0x15, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f,
-	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0c,
+	0x73, 0x74, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0xe4, 0x01, 0x0a, 0x0b, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
+	0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x12, 0x32, 0x0a, 0x14, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14,
+	0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x3b, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x22, 0x4c, 0x0a, 0x12, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x6d, 0x73, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x22,
+	0x3e, 0x0a, 0x0a, 0x46, 0x77, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x73,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22,
+	0x4f, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65,
+	0x12, 0x2e, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0xc5, 0x05, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x33, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x41, 0x64, 0x64,
+	0x46, 0x69, 0x6c, 0x65, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x4e, 0x0a, 0x10, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x46,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x20, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77,
+	0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x48, 0x00, 0x52, 0x10, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x46, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x57, 0x0a, 0x13, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e,
+	0x63, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x41, 0x6e,
+	0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x13, 0x61, 0x6e, 0x6e, 0x6f,
+	0x75, 0x6e, 0x63, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x51, 0x0a, 0x11, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x66, 0x61, 0x62,
+	0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x46, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52,
+	0x11, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x5a, 0x0a, 0x14, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x24, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x46, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x14, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x51,
+	0x0a, 0x11, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x66, 0x61, 0x62, 0x72,
+	0x69, 0x63, 0x6f, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x11,
+	0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x51, 0x0a, 0x11, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x46, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x66,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x41, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x46, 0x61, 0x62,
+	0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48,
+	0x00, 0x52, 0x11, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x57, 0x0a, 0x13, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72,
+	0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x23, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x65, 0x72, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x13, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x09, 0x0a,
+	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x6c, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x46,
+	0x69, 0x6c, 0x65, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69,
+	0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x69,
+	0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
+	0x4e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6f, 0x72, 0x69, 0x67,
+	0x69, 0x6e, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65,
+	0x64, 0x4b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x77, 0x72, 0x61, 0x70,
+	0x70, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x22, 0xcb, 0x01, 0x0a, 0x17, 0x41, 0x6c, 0x6c, 0x6f, 0x77,
+	0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x20,
+	0x0a, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46,
+	0x72, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64,
+	0x46, 0x72, 0x6f, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74,
+	0x69, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55,
+	0x6e, 0x74, 0x69, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x4b,
+	0x65, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65,
+	0x64, 0x4b, 0x65, 0x79, 0x22, 0x4e, 0x0a, 0x1a, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65,
+	0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x22, 0x78, 0x0a, 0x18, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x46, 0x61,
+	0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1e, 0x0a, 0x0a,
+	0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b,
+	0x6e, 0x6f, 0x74, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x6e, 0x6f, 0x74, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65, 0x64, 0x22, 0xf2,
+	0x01, 0x0a, 0x1b, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f,
+	0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x6e,
+	0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73,
+	0x53, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x73, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x41, 0x0a, 0x0a, 0x73, 0x69, 0x6d, 0x75,
+	0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x66,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x53, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52,
+	0x0a, 0x73, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x64,
+	0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x64, 0x69, 0x67,
+	0x65, 0x73, 0x74, 0x22, 0xf4, 0x01, 0x0a, 0x18, 0x53, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x65, 0x78, 0x74, 0x72, 0x75, 0x64,
+	0x65, 0x64, 0x46, 0x69, 0x6c, 0x61, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x10, 0x65, 0x78, 0x74, 0x72, 0x75, 0x64, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x61, 0x6d, 0x65,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x69, 0x6e, 0x58, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x6d, 0x69, 0x6e, 0x58, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x69, 0x6e, 0x59, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x59, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x69,
+	0x6e, 0x5a, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x5a, 0x12, 0x12,
+	0x0a, 0x04, 0x6d, 0x61, 0x78, 0x58, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x61,
+	0x78, 0x58, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x78, 0x59, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x6d, 0x61, 0x78, 0x59, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x78, 0x5a, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6d, 0x61, 0x78, 0x5a, 0x22, 0x58, 0x0a, 0x18, 0x52, 0x65,
+	0x76, 0x6f, 0x6b, 0x65, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61,
+	0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x20, 0x0a, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64,
+	0x4e, 0x6f, 0x64, 0x65, 0x22, 0x76, 0x0a, 0x18, 0x41, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x46, 0x61,
+	0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x20, 0x0a, 0x0b,
+	0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x22, 0x94, 0x01, 0x0a,
+	0x1a, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66,
+	0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66,
+	0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x4b, 0x65,
+	0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64,
+	0x4b, 0x65, 0x79, 0x22, 0x6e, 0x0a, 0x11, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x46, 0x69, 0x6c,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x66, 0x61, 0x62,
+	0x72, 0x69, 0x63, 0x6f, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x48, 0x00, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x48, 0x00, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x06, 0x0a, 0x04, 0x70,
+	0x61, 0x72, 0x74, 0x22, 0x8c, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x46, 0x69,
+	0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x6c,
+	0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04,
+	0x52, 0x0c, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72, 0x6f,
+	0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72,
+	0x6f, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74,
+	0x69, 0x6c, 0x22, 0x50, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x46, 0x69, 0x6c, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65,
+	0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
+	0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x73, 0x22, 0xab, 0x01, 0x0a, 0x17, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x46, 0x61,
+	0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x20, 0x0a, 0x0b,
+	0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72, 0x6f,
+	0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72,
+	0x6f, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74,
+	0x69, 0x6c, 0x22, 0x2e, 0x0a, 0x0e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x22, 0xfd, 0x01, 0x0a, 0x09, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x28, 0x0a, 0x0f,
+	0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69,
+	0x6e, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x1a,
+	0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65,
+	0x76, 0x6f, 0x6b, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72, 0x6f,
+	0x6d, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x72,
+	0x6f, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74,
+	0x69, 0x6c, 0x22, 0x43, 0x0a, 0x0d, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x4c,
+	0x69, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63,
+	0x6f, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x0a, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x2d, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x80, 0x02, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x76, 0x69, 0x65, 0x77, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x76, 0x69,
+	0x65, 0x77, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x76,
+	0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x38, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69,
+	0x63, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3b, 0x0a, 0x0a,
+	0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1b, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x65,
+	0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x0a, 0x73,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x84, 0x01, 0x0a, 0x0b, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x70, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x66, 0x61, 0x62,
+	0x72, 0x69, 0x63, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x22, 0x2a, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x22, 0xa2, 0x01, 0x0a,
+	0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x38,
+	0x0a, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0b, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x32, 0x94, 0x02, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x12, 0x40, 0x0a, 0x10, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f,
+	0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x0b, 0x46, 0x65, 0x74, 0x63, 0x68, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x14, 0x2e, 0x66, 0x61,
+	0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x22, 0x00, 0x30, 0x01, 0x12, 0x3f, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x66,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x50, 0x6f, 0x73, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0f, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f,
+	0x61, 0x64, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x2e, 0x66, 0x61, 0x62, 0x72,
+	0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x1a, 0x15, 0x2e,
+	0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x43,
+	0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x32, 0xe3, 0x02, 0x0a, 0x09, 0x43, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x41, 0x50, 0x49, 0x12, 0x49, 0x0a, 0x0a, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x46, 0x69, 0x6c, 0x65, 0x12, 0x1a, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x53,
+	0x75, 0x62, 0x6d, 0x69, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1b, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69,
+	0x74, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28,
+	0x01, 0x12, 0x4f, 0x0a, 0x10, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e,
+	0x47, 0x72, 0x61, 0x6e, 0x74, 0x46, 0x61, 0x62, 0x72, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63,
+	0x6f, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x42, 0x0a, 0x0e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x41, 0x76, 0x61, 0x69, 0x6c,
+	0x61, 0x62, 0x6c, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x66,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65,
+	0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x12, 0x36, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x12, 0x18, 0x2e, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x47, 0x65, 0x74,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66,
+	0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x3e,
+	0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1b, 0x2e,
+	0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x6f, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x61, 0x62,
+	0x72, 0x69, 0x63, 0x6f, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0c,
 	0x5a, 0x0a, 0x2e, 0x2f, 0x61, 0x70, 0x70, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x33,
 }
@@ -411,30 +2437,82 @@ func file_node_messages_proto_rawDescGZIP() []byte {
 	return file_node_messages_proto_rawDescData
 }
 
-var file_node_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_node_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_node_messages_proto_goTypes = []interface{}{
-	(*ContentChunk)(nil),  // 0: fabrico.ContentChunk
-	(*ContentID)(nil),     // 1: fabrico.ContentID
-	(*BlockPosition)(nil), // 2: fabrico.BlockPosition
-	(*BlockRecord)(nil),   // 3: fabrico.BlockRecord
-	(*FwdMessage)(nil),    // 4: fabrico.FwdMessage
-	(*Consensus)(nil),     // 5: fabrico.Consensus
-	(*anypb.Any)(nil),     // 6: google.protobuf.Any
-	(*emptypb.Empty)(nil), // 7: google.protobuf.Empty
+	(*ContentChunk)(nil),                // 0: fabrico.ContentChunk
+	(*ContentID)(nil),                   // 1: fabrico.ContentID
+	(*BlockPosition)(nil),               // 2: fabrico.BlockPosition
+	(*BlockRecord)(nil),                 // 3: fabrico.BlockRecord
+	(*ConsenterSignature)(nil),          // 4: fabrico.ConsenterSignature
+	(*FwdMessage)(nil),                  // 5: fabrico.FwdMessage
+	(*Consensus)(nil),                   // 6: fabrico.Consensus
+	(*TransactionPayload)(nil),          // 7: fabrico.TransactionPayload
+	(*AddFilePayload)(nil),              // 8: fabrico.AddFilePayload
+	(*AllowFabricationPayload)(nil),     // 9: fabrico.AllowFabricationPayload
+	(*AnnounceFabricationPayload)(nil),  // 10: fabrico.AnnounceFabricationPayload
+	(*CancelFabricationPayload)(nil),    // 11: fabrico.CancelFabricationPayload
+	(*FabricationCompletedPayload)(nil), // 12: fabrico.FabricationCompletedPayload
+	(*SimulationSummaryPayload)(nil),    // 13: fabrico.SimulationSummaryPayload
+	(*RevokeFabricationPayload)(nil),    // 14: fabrico.RevokeFabricationPayload
+	(*AdjustFabricationPayload)(nil),    // 15: fabrico.AdjustFabricationPayload
+	(*TransferFabricationPayload)(nil),  // 16: fabrico.TransferFabricationPayload
+	(*SubmitFileRequest)(nil),           // 17: fabrico.SubmitFileRequest
+	(*SubmitFileMetadata)(nil),          // 18: fabrico.SubmitFileMetadata
+	(*SubmitFileResponse)(nil),          // 19: fabrico.SubmitFileResponse
+	(*GrantFabricationRequest)(nil),     // 20: fabrico.GrantFabricationRequest
+	(*SubmitResponse)(nil),              // 21: fabrico.SubmitResponse
+	(*Allowance)(nil),                   // 22: fabrico.Allowance
+	(*AvailableList)(nil),               // 23: fabrico.AvailableList
+	(*GetBlockRequest)(nil),             // 24: fabrico.GetBlockRequest
+	(*Block)(nil),                       // 25: fabrico.Block
+	(*Transaction)(nil),                 // 26: fabrico.Transaction
+	(*WatchEventsRequest)(nil),          // 27: fabrico.WatchEventsRequest
+	(*Event)(nil),                       // 28: fabrico.Event
+	(*anypb.Any)(nil),                   // 29: google.protobuf.Any
+	(*emptypb.Empty)(nil),               // 30: google.protobuf.Empty
 }
 var file_node_messages_proto_depIdxs = []int32{
-	6, // 0: fabrico.Consensus.message:type_name -> google.protobuf.Any
-	5, // 1: fabrico.NodeExchange.ConsensusMessage:input_type -> fabrico.Consensus
-	2, // 2: fabrico.NodeExchange.FetchBlocks:input_type -> fabrico.BlockPosition
-	1, // 3: fabrico.NodeExchange.DownloadContent:input_type -> fabrico.ContentID
-	7, // 4: fabrico.NodeExchange.ConsensusMessage:output_type -> google.protobuf.Empty
-	3, // 5: fabrico.NodeExchange.FetchBlocks:output_type -> fabrico.BlockRecord
-	0, // 6: fabrico.NodeExchange.DownloadContent:output_type -> fabrico.ContentChunk
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	4,  // 0: fabrico.BlockRecord.signatures:type_name -> fabrico.ConsenterSignature
+	29, // 1: fabrico.Consensus.message:type_name -> google.protobuf.Any
+	8,  // 2: fabrico.TransactionPayload.addFile:type_name -> fabrico.AddFilePayload
+	9,  // 3: fabrico.TransactionPayload.allowFabrication:type_name -> fabrico.AllowFabricationPayload
+	10, // 4: fabrico.TransactionPayload.announceFabrication:type_name -> fabrico.AnnounceFabricationPayload
+	11, // 5: fabrico.TransactionPayload.cancelFabrication:type_name -> fabrico.CancelFabricationPayload
+	12, // 6: fabrico.TransactionPayload.fabricationCompleted:type_name -> fabrico.FabricationCompletedPayload
+	14, // 7: fabrico.TransactionPayload.revokeFabrication:type_name -> fabrico.RevokeFabricationPayload
+	15, // 8: fabrico.TransactionPayload.adjustFabrication:type_name -> fabrico.AdjustFabricationPayload
+	16, // 9: fabrico.TransactionPayload.transferFabrication:type_name -> fabrico.TransferFabricationPayload
+	13, // 10: fabrico.FabricationCompletedPayload.simulation:type_name -> fabrico.SimulationSummaryPayload
+	18, // 11: fabrico.SubmitFileRequest.metadata:type_name -> fabrico.SubmitFileMetadata
+	22, // 12: fabrico.AvailableList.allowances:type_name -> fabrico.Allowance
+	26, // 13: fabrico.Block.transactions:type_name -> fabrico.Transaction
+	4,  // 14: fabrico.Block.signatures:type_name -> fabrico.ConsenterSignature
+	7,  // 15: fabrico.Transaction.payload:type_name -> fabrico.TransactionPayload
+	25, // 16: fabrico.Event.block:type_name -> fabrico.Block
+	26, // 17: fabrico.Event.transaction:type_name -> fabrico.Transaction
+	6,  // 18: fabrico.NodeExchange.ConsensusMessage:input_type -> fabrico.Consensus
+	2,  // 19: fabrico.NodeExchange.FetchBlocks:input_type -> fabrico.BlockPosition
+	30, // 20: fabrico.NodeExchange.BlockHeight:input_type -> google.protobuf.Empty
+	1,  // 21: fabrico.NodeExchange.DownloadContent:input_type -> fabrico.ContentID
+	17, // 22: fabrico.ClientAPI.SubmitFile:input_type -> fabrico.SubmitFileRequest
+	20, // 23: fabrico.ClientAPI.GrantFabrication:input_type -> fabrico.GrantFabricationRequest
+	30, // 24: fabrico.ClientAPI.QueryAvailable:input_type -> google.protobuf.Empty
+	24, // 25: fabrico.ClientAPI.GetBlock:input_type -> fabrico.GetBlockRequest
+	27, // 26: fabrico.ClientAPI.WatchEvents:input_type -> fabrico.WatchEventsRequest
+	30, // 27: fabrico.NodeExchange.ConsensusMessage:output_type -> google.protobuf.Empty
+	3,  // 28: fabrico.NodeExchange.FetchBlocks:output_type -> fabrico.BlockRecord
+	2,  // 29: fabrico.NodeExchange.BlockHeight:output_type -> fabrico.BlockPosition
+	0,  // 30: fabrico.NodeExchange.DownloadContent:output_type -> fabrico.ContentChunk
+	19, // 31: fabrico.ClientAPI.SubmitFile:output_type -> fabrico.SubmitFileResponse
+	21, // 32: fabrico.ClientAPI.GrantFabrication:output_type -> fabrico.SubmitResponse
+	23, // 33: fabrico.ClientAPI.QueryAvailable:output_type -> fabrico.AvailableList
+	25, // 34: fabrico.ClientAPI.GetBlock:output_type -> fabrico.Block
+	28, // 35: fabrico.ClientAPI.WatchEvents:output_type -> fabrico.Event
+	27, // [27:36] is the sub-list for method output_type
+	18, // [18:27] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_node_messages_proto_init() }
@@ -492,7 +2570,7 @@ func file_node_messages_proto_init() {
 			}
 		}
 		file_node_messages_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FwdMessage); i {
+			switch v := v.(*ConsenterSignature); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -504,6 +2582,18 @@ func file_node_messages_proto_init() {
 			}
 		}
 		file_node_messages_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FwdMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Consensus); i {
 			case 0:
 				return &v.state
@@ -515,6 +2605,288 @@ func file_node_messages_proto_init() {
 				return nil
 			}
 		}
+		file_node_messages_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransactionPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddFilePayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AllowFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnnounceFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CancelFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FabricationCompletedPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SimulationSummaryPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AdjustFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransferFabricationPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitFileRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitFileMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitFileResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GrantFabricationRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubmitResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Allowance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AvailableList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Transaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchEventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_node_messages_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_node_messages_proto_msgTypes[7].OneofWrappers = []interface{}{
+		(*TransactionPayload_AddFile)(nil),
+		(*TransactionPayload_AllowFabrication)(nil),
+		(*TransactionPayload_AnnounceFabrication)(nil),
+		(*TransactionPayload_CancelFabrication)(nil),
+		(*TransactionPayload_FabricationCompleted)(nil),
+		(*TransactionPayload_RevokeFabrication)(nil),
+		(*TransactionPayload_AdjustFabrication)(nil),
+		(*TransactionPayload_TransferFabrication)(nil),
+	}
+	file_node_messages_proto_msgTypes[17].OneofWrappers = []interface{}{
+		(*SubmitFileRequest_Metadata)(nil),
+		(*SubmitFileRequest_Chunk)(nil),
+	}
+	file_node_messages_proto_msgTypes[28].OneofWrappers = []interface{}{
+		(*Event_Block)(nil),
+		(*Event_Transaction)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -522,9 +2894,9 @@ func file_node_messages_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_node_messages_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   29,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_node_messages_proto_goTypes,
 		DependencyIndexes: file_node_messages_proto_depIdxs,
@@ -25,6 +25,7 @@ const _ = grpc.SupportPackageIsVersion7
 type NodeExchangeClient interface {
 	ConsensusMessage(ctx context.Context, in *Consensus, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	FetchBlocks(ctx context.Context, in *BlockPosition, opts ...grpc.CallOption) (NodeExchange_FetchBlocksClient, error)
+	BlockHeight(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockPosition, error)
 	DownloadContent(ctx context.Context, in *ContentID, opts ...grpc.CallOption) (NodeExchange_DownloadContentClient, error)
 }
 
@@ -77,6 +78,15 @@ func (x *nodeExchangeFetchBlocksClient) Recv() (*BlockRecord, error) {
 	return m, nil
 }
 
+func (c *nodeExchangeClient) BlockHeight(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockPosition, error) {
+	out := new(BlockPosition)
+	err := c.cc.Invoke(ctx, "/fabrico.NodeExchange/BlockHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *nodeExchangeClient) DownloadContent(ctx context.Context, in *ContentID, opts ...grpc.CallOption) (NodeExchange_DownloadContentClient, error) {
 	stream, err := c.cc.NewStream(ctx, &NodeExchange_ServiceDesc.Streams[1], "/fabrico.NodeExchange/DownloadContent", opts...)
 	if err != nil {
@@ -115,6 +125,7 @@ func (x *nodeExchangeDownloadContentClient) Recv() (*ContentChunk, error) {
 type NodeExchangeServer interface {
 	ConsensusMessage(context.Context, *Consensus) (*emptypb.Empty, error)
 	FetchBlocks(*BlockPosition, NodeExchange_FetchBlocksServer) error
+	BlockHeight(context.Context, *emptypb.Empty) (*BlockPosition, error)
 	DownloadContent(*ContentID, NodeExchange_DownloadContentServer) error
 	mustEmbedUnimplementedNodeExchangeServer()
 }
@@ -129,6 +140,9 @@ func (UnimplementedNodeExchangeServer) ConsensusMessage(context.Context, *Consen
 func (UnimplementedNodeExchangeServer) FetchBlocks(*BlockPosition, NodeExchange_FetchBlocksServer) error {
 	return status.Errorf(codes.Unimplemented, "method FetchBlocks not implemented")
 }
+func (UnimplementedNodeExchangeServer) BlockHeight(context.Context, *emptypb.Empty) (*BlockPosition, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockHeight not implemented")
+}
 func (UnimplementedNodeExchangeServer) DownloadContent(*ContentID, NodeExchange_DownloadContentServer) error {
 	return status.Errorf(codes.Unimplemented, "method DownloadContent not implemented")
 }
@@ -184,6 +198,24 @@ func (x *nodeExchangeFetchBlocksServer) Send(m *BlockRecord) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _NodeExchange_BlockHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeExchangeServer).BlockHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fabrico.NodeExchange/BlockHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeExchangeServer).BlockHeight(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _NodeExchange_DownloadContent_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(ContentID)
 	if err := stream.RecvMsg(m); err != nil {
@@ -216,6 +248,10 @@ var NodeExchange_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ConsensusMessage",
 			Handler:    _NodeExchange_ConsensusMessage_Handler,
 		},
+		{
+			MethodName: "BlockHeight",
+			Handler:    _NodeExchange_BlockHeight_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -231,3 +267,295 @@ var NodeExchange_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "node_messages.proto",
 }
+
+// ClientAPIClient is the client API for ClientAPI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ClientAPIClient interface {
+	SubmitFile(ctx context.Context, opts ...grpc.CallOption) (ClientAPI_SubmitFileClient, error)
+	GrantFabrication(ctx context.Context, in *GrantFabricationRequest, opts ...grpc.CallOption) (*SubmitResponse, error)
+	QueryAvailable(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AvailableList, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ClientAPI_WatchEventsClient, error)
+}
+
+type clientAPIClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClientAPIClient(cc grpc.ClientConnInterface) ClientAPIClient {
+	return &clientAPIClient{cc}
+}
+
+func (c *clientAPIClient) SubmitFile(ctx context.Context, opts ...grpc.CallOption) (ClientAPI_SubmitFileClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ClientAPI_ServiceDesc.Streams[0], "/fabrico.ClientAPI/SubmitFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clientAPISubmitFileClient{stream}
+	return x, nil
+}
+
+type ClientAPI_SubmitFileClient interface {
+	Send(*SubmitFileRequest) error
+	CloseAndRecv() (*SubmitFileResponse, error)
+	grpc.ClientStream
+}
+
+type clientAPISubmitFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *clientAPISubmitFileClient) Send(m *SubmitFileRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *clientAPISubmitFileClient) CloseAndRecv() (*SubmitFileResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SubmitFileResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *clientAPIClient) GrantFabrication(ctx context.Context, in *GrantFabricationRequest, opts ...grpc.CallOption) (*SubmitResponse, error) {
+	out := new(SubmitResponse)
+	err := c.cc.Invoke(ctx, "/fabrico.ClientAPI/GrantFabrication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientAPIClient) QueryAvailable(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AvailableList, error) {
+	out := new(AvailableList)
+	err := c.cc.Invoke(ctx, "/fabrico.ClientAPI/QueryAvailable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientAPIClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/fabrico.ClientAPI/GetBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientAPIClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ClientAPI_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ClientAPI_ServiceDesc.Streams[1], "/fabrico.ClientAPI/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clientAPIWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClientAPI_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type clientAPIWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *clientAPIWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClientAPIServer is the server API for ClientAPI service.
+// All implementations must embed UnimplementedClientAPIServer
+// for forward compatibility
+type ClientAPIServer interface {
+	SubmitFile(ClientAPI_SubmitFileServer) error
+	GrantFabrication(context.Context, *GrantFabricationRequest) (*SubmitResponse, error)
+	QueryAvailable(context.Context, *emptypb.Empty) (*AvailableList, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	WatchEvents(*WatchEventsRequest, ClientAPI_WatchEventsServer) error
+	mustEmbedUnimplementedClientAPIServer()
+}
+
+// UnimplementedClientAPIServer must be embedded to have forward compatible implementations.
+type UnimplementedClientAPIServer struct {
+}
+
+func (UnimplementedClientAPIServer) SubmitFile(ClientAPI_SubmitFileServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubmitFile not implemented")
+}
+func (UnimplementedClientAPIServer) GrantFabrication(context.Context, *GrantFabricationRequest) (*SubmitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantFabrication not implemented")
+}
+func (UnimplementedClientAPIServer) QueryAvailable(context.Context, *emptypb.Empty) (*AvailableList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryAvailable not implemented")
+}
+func (UnimplementedClientAPIServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedClientAPIServer) WatchEvents(*WatchEventsRequest, ClientAPI_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedClientAPIServer) mustEmbedUnimplementedClientAPIServer() {}
+
+// UnsafeClientAPIServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClientAPIServer will
+// result in compilation errors.
+type UnsafeClientAPIServer interface {
+	mustEmbedUnimplementedClientAPIServer()
+}
+
+func RegisterClientAPIServer(s grpc.ServiceRegistrar, srv ClientAPIServer) {
+	s.RegisterService(&ClientAPI_ServiceDesc, srv)
+}
+
+func _ClientAPI_SubmitFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ClientAPIServer).SubmitFile(&clientAPISubmitFileServer{stream})
+}
+
+type ClientAPI_SubmitFileServer interface {
+	SendAndClose(*SubmitFileResponse) error
+	Recv() (*SubmitFileRequest, error)
+	grpc.ServerStream
+}
+
+type clientAPISubmitFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *clientAPISubmitFileServer) SendAndClose(m *SubmitFileResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *clientAPISubmitFileServer) Recv() (*SubmitFileRequest, error) {
+	m := new(SubmitFileRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ClientAPI_GrantFabrication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantFabricationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientAPIServer).GrantFabrication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fabrico.ClientAPI/GrantFabrication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientAPIServer).GrantFabrication(ctx, req.(*GrantFabricationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientAPI_QueryAvailable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientAPIServer).QueryAvailable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fabrico.ClientAPI/QueryAvailable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientAPIServer).QueryAvailable(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientAPI_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientAPIServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/fabrico.ClientAPI/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientAPIServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientAPI_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClientAPIServer).WatchEvents(m, &clientAPIWatchEventsServer{stream})
+}
+
+type ClientAPI_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type clientAPIWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *clientAPIWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ClientAPI_ServiceDesc is the grpc.ServiceDesc for ClientAPI service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClientAPI_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fabrico.ClientAPI",
+	HandlerType: (*ClientAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GrantFabrication",
+			Handler:    _ClientAPI_GrantFabrication_Handler,
+		},
+		{
+			MethodName: "QueryAvailable",
+			Handler:    _ClientAPI_QueryAvailable_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _ClientAPI_GetBlock_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitFile",
+			Handler:       _ClientAPI_SubmitFile_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _ClientAPI_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "node_messages.proto",
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/asn1"
+	"math"
+
+	"github.com/fabian-z/fabrico-ledger/gcodesim"
+	"golang.org/x/crypto/sha3"
+)
+
+// FabricationReceipt is the content of a FabricationCompleted request, written by the
+// fabricating node after a job finished.
+// Lengths are given in micrometres, since ASN1 cannot serialize floats.
+type FabricationReceipt struct {
+	FileHash     []byte
+	AnnounceID   string // announcement reserving the produced part
+	Success      bool
+	CommandsSent int64
+	Simulation   SimulationSummary
+	Digest       []byte // SHA3-256 of the ASN.1 encoded simulation summary
+}
+
+// SimulationSummary is the result of simulating the fabrication data with gcodesim
+type SimulationSummary struct {
+	Simulated        bool // false if the data could not be simulated
+	Layers           int64
+	ExtrudedFilament int64
+	MinX, MinY, MinZ int64
+	MaxX, MaxY, MaxZ int64
+}
+
+func micrometres(mm float64) int64 {
+	return int64(math.Round(mm * 1000))
+}
+
+func newSimulationSummary(printer *gcodesim.Printer) SimulationSummary {
+	summary := printer.Summary()
+	return SimulationSummary{
+		Simulated:        true,
+		Layers:           int64(summary.Layers),
+		ExtrudedFilament: micrometres(summary.ExtrudedFilament),
+		MinX:             micrometres(summary.Min.X),
+		MinY:             micrometres(summary.Min.Y),
+		MinZ:             micrometres(summary.Min.Z),
+		MaxX:             micrometres(summary.Max.X),
+		MaxY:             micrometres(summary.Max.Y),
+		MaxZ:             micrometres(summary.Max.Z),
+	}
+}
+
+// Digest returns the SHA3-256 hash of the ASN.1 encoded summary
+func (s SimulationSummary) Digest() []byte {
+	rawSummary, err := asn1.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	digest := sha3.Sum256(rawSummary)
+	return digest[:]
+}
+
+func (r FabricationReceipt) toBytes() []byte {
+	rawReceipt, err := asn1.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return rawReceipt
+}
+
+func receiptFromPayload(p *FabricationCompletedPayload) *FabricationReceipt {
+	sim := p.GetSimulation()
+	return &FabricationReceipt{
+		FileHash:     p.FileHash,
+		AnnounceID:   p.AnnounceId,
+		Success:      p.Success,
+		CommandsSent: p.CommandsSent,
+		Simulation: SimulationSummary{
+			Simulated:        sim.GetSimulated(),
+			Layers:           sim.GetLayers(),
+			ExtrudedFilament: sim.GetExtrudedFilament(),
+			MinX:             sim.GetMinX(),
+			MinY:             sim.GetMinY(),
+			MinZ:             sim.GetMinZ(),
+			MaxX:             sim.GetMaxX(),
+			MaxY:             sim.GetMaxY(),
+			MaxZ:             sim.GetMaxZ(),
+		},
+		Digest: p.Digest,
+	}
+}
+
+func (r FabricationReceipt) toPayload() *FabricationCompletedPayload {
+	return &FabricationCompletedPayload{
+		FileHash:     r.FileHash,
+		AnnounceId:   r.AnnounceID,
+		Success:      r.Success,
+		CommandsSent: r.CommandsSent,
+		Simulation: &SimulationSummaryPayload{
+			Simulated:        r.Simulation.Simulated,
+			Layers:           r.Simulation.Layers,
+			ExtrudedFilament: r.Simulation.ExtrudedFilament,
+			MinX:             r.Simulation.MinX,
+			MinY:             r.Simulation.MinY,
+			MinZ:             r.Simulation.MinZ,
+			MaxX:             r.Simulation.MaxX,
+			MaxY:             r.Simulation.MaxY,
+			MaxZ:             r.Simulation.MaxZ,
+		},
+		Digest: r.Digest,
+	}
+}
@@ -17,12 +17,14 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"strings"
 	"time"
 )
 
 var (
 	cn         = flag.String("cn", "", "Common Name to generate certificate for")
 	host       = flag.String("host", "", "Comma-separated hostnames and IPs to generate a certificate for")
+	roles      = flag.String("roles", "", "Comma-separated API roles (provider, logistics, manufacturer) of a user certificate")
 	caCertPath = flag.String("ca-cert", "ca.crt", "CA certificate path to use when generating node key, PEM encoded")
 	caKeyPath  = flag.String("ca-key", "ca.key", "CA key path to use when generating node key, PEM encoded")
 
@@ -34,7 +36,9 @@ var (
 func main() {
 	flag.Parse()
 
-	if (len(*host) == 0 && !(*isCA)) || (len(*host) != 0 && (*isCA)) {
+	// User certificates authenticate API clients and carry no host
+	isUser := len(*roles) != 0
+	if (len(*host) == 0 && !(*isCA) && !isUser) || (len(*host) != 0 && (*isCA || isUser)) || (*isCA && isUser) {
 		log.Fatalf("Missing or invalid host / CA selection")
 	}
 
@@ -80,7 +84,10 @@ func main() {
 		BasicConstraintsValid: true,
 	}
 
-	if ip := net.ParseIP(*host); ip != nil {
+	if isUser {
+		// API roles are read from the organizational units
+		template.Subject.OrganizationalUnit = strings.Split(*roles, ",")
+	} else if ip := net.ParseIP(*host); ip != nil {
 		template.IPAddresses = append(template.IPAddresses, ip)
 	} else {
 		template.DNSNames = append(template.DNSNames, *host)
@@ -97,6 +104,9 @@ func main() {
 	} else {
 		// Generate P2P Certificates
 		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		if isUser {
+			template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		}
 
 		// Load CA from ca.pem
 		caCert, caKey, err := LoadX509KeyPair("ca.crt", "ca.key")
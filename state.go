@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type AllowCount struct {
+	NodeID         NodeID
+	RemainingCount int
+	ReservedCount  int // announced parts, pending production
+	ConsumedCount  int // produced parts
+	Revoked        bool
+
+	// Validity window in ledger time as Unix seconds, 0 is unbounded
+	ValidFrom  int64
+	ValidUntil int64 // exclusive
+}
+
+// validAt returns whether the allowance may be used at the given ledger time
+func (c *AllowCount) validAt(timestamp int64) bool {
+	return timestamp >= c.ValidFrom && (c.ValidUntil == 0 || timestamp < c.ValidUntil)
+}
+
+// expiredAt returns whether the validity window ended before the given ledger time
+func (c *AllowCount) expiredAt(timestamp int64) bool {
+	return c.ValidUntil != 0 && timestamp >= c.ValidUntil
+}
+
+// Grant records the origin of allowance parts, either granted by the originating node
+// or transferred from another allowed node
+type Grant struct {
+	ID        string // request ID
+	FileHash  FabricationDataHash
+	From      NodeID
+	To        NodeID
+	Count     int
+	Transfer  bool  // false for AllowFabrication by the originating node
+	Timestamp int64 // ledger time of the batch
+}
+
+// Reservation tracks an AnnounceFabrication request until it is closed by CancelFabrication
+type Reservation struct {
+	ID       string // announce request ID
+	FileHash FabricationDataHash
+	NodeID   NodeID
+	Count    int // announced parts
+	Released int // parts not produced, returned to the allowance on cancellation
+	Closed   bool
+
+	Receipt *FabricationReceipt // written by the fabricating node after the job finished
+}
+
+// ledgerState is the state machine aggregating committed requests.
+// Batches are applied synchronously in order of delivery under a single lock,
+// so every node derives the same state for the same sequence of batches.
+// Applying only touches in-memory maps and does not block consensus noticeably.
+type ledgerState struct {
+	sync.RWMutex
+
+	knownFiles   map[FabricationDataHash]NodeID // mapping from file hash to originating node id
+	allowedNodes map[FabricationDataHash][]*AllowCount
+	reservations map[string]*Reservation          // indexed by announce request ID
+	grants       map[FabricationDataHash][]*Grant // in order of commitment
+
+	// Ledger time of the latest applied batch, agreed on through consensus
+	time int64
+
+	// Committed state below a copy-on-write overlay, nil for the committed state itself
+	parent *ledgerState
+}
+
+func newLedgerState() *ledgerState {
+	return &ledgerState{
+		knownFiles:   make(map[FabricationDataHash]NodeID),
+		allowedNodes: make(map[FabricationDataHash][]*AllowCount),
+		reservations: make(map[string]*Reservation),
+		grants:       make(map[FabricationDataHash][]*Grant),
+	}
+}
+
+// overlay returns a copy-on-write view of the state for checking and applying requests
+// without changing the state. Entries are copied into the overlay when they are first accessed,
+// so the state must be read locked while the overlay is in use.
+func (s *ledgerState) overlay() *ledgerState {
+	o := newLedgerState()
+	o.time = s.time
+	o.parent = s
+	return o
+}
+
+// fileOwner returns the originating node of the given file
+func (s *ledgerState) fileOwner(address FabricationDataHash) (NodeID, bool) {
+	owner, ok := s.knownFiles[address]
+	if ok || s.parent == nil {
+		return owner, ok
+	}
+	return s.parent.fileOwner(address)
+}
+
+// allowCounts returns the allowances for the given file
+func (s *ledgerState) allowCounts(address FabricationDataHash) []*AllowCount {
+	counts, ok := s.allowedNodes[address]
+	if ok || s.parent == nil {
+		return counts
+	}
+
+	for _, count := range s.parent.allowCounts(address) {
+		countCopy := *count
+		counts = append(counts, &countCopy)
+	}
+	s.allowedNodes[address] = counts
+	return counts
+}
+
+// reservation returns the reservation of the given announce request, nil if none exists
+func (s *ledgerState) reservation(id string) *Reservation {
+	reservation, ok := s.reservations[id]
+	if ok || s.parent == nil {
+		return reservation
+	}
+
+	if reservation = s.parent.reservation(id); reservation != nil {
+		reservationCopy := *reservation
+		reservation = &reservationCopy
+		s.reservations[id] = reservation
+	}
+	return reservation
+}
+
+// addGrant records a grant for the given file
+func (s *ledgerState) addGrant(grant *Grant) {
+	grants, ok := s.grants[grant.FileHash]
+	if !ok && s.parent != nil {
+		// Grants are never modified after creation
+		grants = append([]*Grant(nil), s.parent.grants[grant.FileHash]...)
+	}
+	s.grants[grant.FileHash] = append(grants, grant)
+}
+
+// allowCount returns the allowance of the given node for the given file, nil if none exists
+func (s *ledgerState) allowCount(address FabricationDataHash, node NodeID) *AllowCount {
+	for _, count := range s.allowCounts(address) {
+		if count.NodeID == node {
+			return count
+		}
+	}
+	return nil
+}
+
+// check verifies that the request is valid in the current state.
+// Requests have to pass the stateless Request.validate before.
+func (s *ledgerState) check(request *Request) error {
+	if request.Type == SystemReserved {
+		return nil
+	}
+
+	payload, err := request.decodePayload()
+	if err != nil {
+		return err
+	}
+	submitter, _ := nodeIDFromClientID(request.ClientID)
+
+	switch request.Type {
+	case AddFile:
+		address := fileHash(payload.GetAddFile().FileHash)
+
+		if owner, ok := s.fileOwner(address); ok {
+			return fmt.Errorf("file %x already owned by node %v", address[:8], owner)
+		}
+
+	case AllowFabrication:
+		address := fileHash(payload.GetAllowFabrication().FileHash)
+
+		owner, ok := s.fileOwner(address)
+		if !ok {
+			return fmt.Errorf("%w %x", ErrUnknownFile, address[:8])
+		}
+
+		if owner != submitter {
+			return fmt.Errorf("only originating node %v may allow fabrication of file %x", owner, address[:8])
+		}
+
+	case RevokeFabrication, AdjustFabrication:
+		var address FabricationDataHash
+		var allowedNode NodeID
+		if request.Type == RevokeFabrication {
+			address = fileHash(payload.GetRevokeFabrication().FileHash)
+			allowedNode = NodeID(payload.GetRevokeFabrication().AllowedNode)
+		} else {
+			address = fileHash(payload.GetAdjustFabrication().FileHash)
+			allowedNode = NodeID(payload.GetAdjustFabrication().AllowedNode)
+		}
+
+		owner, ok := s.fileOwner(address)
+		if !ok {
+			return fmt.Errorf("%w %x", ErrUnknownFile, address[:8])
+		}
+
+		if owner != submitter {
+			return fmt.Errorf("only originating node %v may change allowances of file %x", owner, address[:8])
+		}
+
+		count := s.allowCount(address, allowedNode)
+		if count == nil {
+			return fmt.Errorf("node %v has no allowance for file %x", allowedNode, address[:8])
+		}
+		if count.Revoked {
+			return fmt.Errorf("allowance of node %v for file %x is revoked", allowedNode, address[:8])
+		}
+
+	case TransferFabrication:
+		p := payload.GetTransferFabrication()
+		address := fileHash(p.FileHash)
+
+		if _, ok := s.fileOwner(address); !ok {
+			return fmt.Errorf("%w %x", ErrUnknownFile, address[:8])
+		}
+
+		count := s.allowCount(address, submitter)
+		if count == nil || count.RemainingCount < int(p.Count) {
+			return fmt.Errorf("node %v cannot transfer %d parts of file %x", submitter, p.Count, address[:8])
+		}
+		if count.Revoked || !count.validAt(s.time) {
+			return fmt.Errorf("allowance of node %v for file %x is not valid", submitter, address[:8])
+		}
+
+		receiver := NodeID(p.ReceivingNode)
+		if receiverCount := s.allowCount(address, receiver); receiverCount != nil {
+			if receiverCount.Revoked {
+				return fmt.Errorf("allowance of node %v for file %x is revoked", receiver, address[:8])
+			}
+			// Transferred parts would otherwise be fabricable in the window of the receiver
+			if receiverCount.ValidFrom != count.ValidFrom || receiverCount.ValidUntil != count.ValidUntil {
+				return fmt.Errorf("allowance of node %v for file %x has another validity window", receiver, address[:8])
+			}
+		}
+
+	case AnnounceFabrication:
+		p := payload.GetAnnounceFabrication()
+		address := fileHash(p.FileHash)
+
+		if s.reservation(request.ID) != nil {
+			return fmt.Errorf("announcement %v already exists", request.ID)
+		}
+
+		count := s.allowCount(address, submitter)
+		if count == nil || count.RemainingCount < int(p.Count) {
+			return fmt.Errorf("node %v is not allowed to fabricate %d parts of file %x", submitter, p.Count, address[:8])
+		}
+		if !count.validAt(s.time) {
+			return fmt.Errorf("allowance of node %v for file %x is not valid at ledger time %d", submitter, address[:8], s.time)
+		}
+
+	case CancelFabrication:
+		p := payload.GetCancelFabrication()
+		address := fileHash(p.FileHash)
+
+		reservation := s.reservation(p.AnnounceId)
+		if reservation == nil || reservation.FileHash != address {
+			return fmt.Errorf("no announcement %v for file %x", p.AnnounceId, address[:8])
+		}
+
+		if reservation.NodeID != submitter {
+			return fmt.Errorf("announcement %v belongs to node %v", p.AnnounceId, reservation.NodeID)
+		}
+		if reservation.Closed {
+			return fmt.Errorf("announcement %v already cancelled", p.AnnounceId)
+		}
+		if int(p.NotProduced) > reservation.Count {
+			return fmt.Errorf("cannot cancel %d of %d announced parts", p.NotProduced, reservation.Count)
+		}
+
+	case FabricationCompleted:
+		p := payload.GetFabricationCompleted()
+
+		reservation := s.reservation(p.AnnounceId)
+		if reservation == nil || !bytes.Equal(reservation.FileHash[:], p.FileHash) {
+			return fmt.Errorf("no announcement %v for file %x", p.AnnounceId, p.FileHash[:8])
+		}
+
+		if reservation.NodeID != submitter {
+			return fmt.Errorf("announcement %v belongs to node %v", p.AnnounceId, reservation.NodeID)
+		}
+		if reservation.Receipt != nil {
+			return fmt.Errorf("receipt for announcement %v already exists", p.AnnounceId)
+		}
+	}
+
+	return nil
+}
+
+// apply applies all requests of the given record to the state
+// Returns the reasons for skipped requests by request ID
+func (s *ledgerState) apply(record *AppRecord) map[string]error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.advanceTime(record.Timestamp())
+
+	var skipped map[string]error
+	for _, reqBytes := range record.Batch.Requests {
+		request, err := parseRequest(reqBytes)
+		if err != nil {
+			continue
+		}
+		// Proposals are verified by consenters, skipping keeps the state machine total for invalid records
+		err = request.validate()
+		if err == nil {
+			err = s.check(request)
+		}
+		if err != nil {
+			if skipped == nil {
+				skipped = make(map[string]error)
+			}
+			skipped[request.ID] = err
+			continue
+		}
+		s.applyRequest(request)
+	}
+	return skipped
+}
+
+// advanceTime sets the ledger time, which never moves backwards
+func (s *ledgerState) advanceTime(timestamp int64) {
+	if timestamp > s.time {
+		s.time = timestamp
+	}
+}
+
+// Time returns the ledger time of the latest applied batch
+func (s *ledgerState) Time() int64 {
+	s.RLock()
+	defer s.RUnlock()
+	return s.time
+}
+
+func (s *ledgerState) applyRequest(request *Request) {
+	if request.Type == SystemReserved {
+		// No aggregations from system messages
+		return
+	}
+
+	// Requests passed validate and check, so the payload is well-formed
+	payload, _ := request.decodePayload()
+
+	switch request.Type {
+	case AddFile:
+		p := payload.GetAddFile()
+		s.knownFiles[fileHash(p.FileHash)] = NodeID(p.OriginNode)
+
+		// TODO difference between originating and distributing nodes
+
+	case AllowFabrication:
+		p := payload.GetAllowFabrication()
+		address := fileHash(p.FileHash)
+		allowedNode := NodeID(p.AllowedNode)
+
+		owner, _ := s.fileOwner(address)
+		s.addGrant(&Grant{
+			ID:        request.ID,
+			FileHash:  address,
+			From:      owner,
+			To:        allowedNode,
+			Count:     int(p.Count),
+			Timestamp: s.time,
+		})
+
+		// Check if node has allow count, granting again lifts a revocation and replaces the validity window
+		for _, count := range s.allowCounts(address) {
+			if count.NodeID == allowedNode {
+				count.RemainingCount = count.RemainingCount + int(p.Count)
+				count.Revoked = false
+				count.ValidFrom = p.ValidFrom
+				count.ValidUntil = p.ValidUntil
+				return
+			}
+		}
+
+		// Node needs new allow count
+		s.allowedNodes[address] = append(s.allowCounts(address), &AllowCount{
+			NodeID:         allowedNode,
+			RemainingCount: int(p.Count),
+			ValidFrom:      p.ValidFrom,
+			ValidUntil:     p.ValidUntil,
+		})
+
+	case TransferFabrication:
+		p := payload.GetTransferFabrication()
+		address := fileHash(p.FileHash)
+
+		sender, _ := nodeIDFromClientID(request.ClientID)
+		receiver := NodeID(p.ReceivingNode)
+
+		count := s.allowCount(address, sender)
+		count.RemainingCount -= int(p.Count)
+
+		s.addGrant(&Grant{
+			ID:        request.ID,
+			FileHash:  address,
+			From:      sender,
+			To:        receiver,
+			Count:     int(p.Count),
+			Transfer:  true,
+			Timestamp: s.time,
+		})
+
+		// Sub-licenses cannot outlive the allowance they were transferred from,
+		// existing allowances of the receiver have the same validity window, see check
+		if receiverCount := s.allowCount(address, receiver); receiverCount != nil {
+			receiverCount.RemainingCount += int(p.Count)
+			return
+		}
+		s.allowedNodes[address] = append(s.allowCounts(address), &AllowCount{
+			NodeID:         receiver,
+			RemainingCount: int(p.Count),
+			ValidFrom:      count.ValidFrom,
+			ValidUntil:     count.ValidUntil,
+		})
+
+	case AnnounceFabrication:
+		p := payload.GetAnnounceFabrication()
+		address := fileHash(p.FileHash)
+
+		node, _ := nodeIDFromClientID(request.ClientID)
+		count := s.allowCount(address, node)
+		count.RemainingCount -= int(p.Count)
+		count.ReservedCount += int(p.Count)
+
+		s.reservations[request.ID] = &Reservation{
+			ID:       request.ID,
+			FileHash: address,
+			NodeID:   node,
+			Count:    int(p.Count),
+		}
+
+	case CancelFabrication:
+		p := payload.GetCancelFabrication()
+		reservation := s.reservation(p.AnnounceId)
+		reservation.Released = int(p.NotProduced)
+		reservation.Closed = true
+
+		count := s.allowCount(reservation.FileHash, reservation.NodeID)
+		count.ReservedCount -= reservation.Count
+		count.ConsumedCount += reservation.Count - reservation.Released
+		// Parts not produced are not returned to revoked allowances
+		if !count.Revoked {
+			count.RemainingCount += reservation.Released
+		}
+
+	case RevokeFabrication:
+		p := payload.GetRevokeFabrication()
+
+		// Reserved parts stay reserved, they might already be in production
+		count := s.allowCount(fileHash(p.FileHash), NodeID(p.AllowedNode))
+		count.RemainingCount = 0
+		count.Revoked = true
+
+	case AdjustFabrication:
+		p := payload.GetAdjustFabrication()
+
+		count := s.allowCount(fileHash(p.FileHash), NodeID(p.AllowedNode))
+		count.RemainingCount = int(p.Remaining)
+
+	case FabricationCompleted:
+		receipt := receiptFromPayload(payload.GetFabricationCompleted())
+		s.reservation(receipt.AnnounceID).Receipt = receipt
+	}
+}
+
+// receipts returns all reservations with fabrication receipts for files originating at the given node
+func (s *ledgerState) receipts(owner NodeID) []*Reservation {
+	s.RLock()
+	defer s.RUnlock()
+
+	var res []*Reservation
+	for _, reservation := range s.reservations {
+		if reservation.Receipt != nil && s.knownFiles[reservation.FileHash] == owner {
+			reservationCopy := *reservation
+			res = append(res, &reservationCopy)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// provenance returns the grants through which the given node received parts of its allowance,
+// following transfers back to the originating node, in order of commitment
+func (s *ledgerState) provenance(address FabricationDataHash, node NodeID) []*Grant {
+	s.RLock()
+	defer s.RUnlock()
+
+	grants := s.grants[address]
+	included := make([]bool, len(grants))
+	recipients := map[NodeID]bool{node: true}
+
+	// Transfers only pass previously received parts, so a single pass in reverse order
+	// visits every grant before the grants it depends on
+	for i := len(grants) - 1; i >= 0; i-- {
+		if recipients[grants[i].To] {
+			included[i] = true
+			if grants[i].Transfer {
+				recipients[grants[i].From] = true
+			}
+		}
+	}
+
+	var res []*Grant
+	for i, grant := range grants {
+		if included[i] {
+			grantCopy := *grant
+			res = append(res, &grantCopy)
+		}
+	}
+	return res
+}
+
+// FileAllowance is a copy of an allowance together with the file it applies to
+type FileAllowance struct {
+	AllowCount
+	FileHash FabricationDataHash
+	Owner    NodeID
+}
+
+// available returns the allowances of the given node which are not expired at the given ledger time
+// and have parts remaining, pending or were revoked, ordered by file hash
+func (s *ledgerState) available(node NodeID, timestamp int64) []*FileAllowance {
+	s.RLock()
+	defer s.RUnlock()
+
+	var res []*FileAllowance
+	for hash, allowed := range s.allowedNodes {
+		for _, allow := range allowed {
+			if node != allow.NodeID || allow.expiredAt(timestamp) {
+				continue
+			}
+			if allow.RemainingCount > 0 || allow.ReservedCount > 0 || allow.Revoked {
+				res = append(res, &FileAllowance{
+					AllowCount: *allow,
+					FileHash:   hash,
+					Owner:      s.knownFiles[hash],
+				})
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i].FileHash[:], res[j].FileHash[:]) < 0
+	})
+	return res
+}
+
+// owner returns the originating node of the given file
+func (s *ledgerState) owner(address FabricationDataHash) (NodeID, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	owner, ok := s.knownFiles[address]
+	return owner, ok
+}
+
+// Canonical state encoding for computing the state root
+type stateRootFile struct {
+	Hash    []byte
+	Owner   int64
+	Allowed []stateRootAllowCount
+	Grants  []stateRootGrant
+}
+
+type stateRootGrant struct {
+	ID        string
+	From      int64
+	To        int64
+	Count     int64
+	Transfer  bool
+	Timestamp int64
+}
+
+type stateRootAllowCount struct {
+	NodeID         int64
+	RemainingCount int64
+	ReservedCount  int64
+	ConsumedCount  int64
+	Revoked        bool
+	ValidFrom      int64
+	ValidUntil     int64
+}
+
+type stateRootReservation struct {
+	ID       string
+	FileHash []byte
+	NodeID   int64
+	Count    int64
+	Released int64
+	Closed   bool
+	Receipt  []byte
+}
+
+// Root returns the SHA3-256 hash of a canonical encoding of the state.
+// Nodes which applied the same batches return the same root.
+func (s *ledgerState) Root() []byte {
+	s.RLock()
+	defer s.RUnlock()
+
+	hashes := make(map[FabricationDataHash]struct{})
+	for hash := range s.knownFiles {
+		hashes[hash] = struct{}{}
+	}
+	for hash := range s.allowedNodes {
+		hashes[hash] = struct{}{}
+	}
+	for hash := range s.grants {
+		hashes[hash] = struct{}{}
+	}
+
+	files := make([]stateRootFile, 0, len(hashes))
+	for hash := range hashes {
+		file := stateRootFile{
+			Hash:    append([]byte(nil), hash[:]...),
+			Owner:   int64(s.knownFiles[hash]),
+			Allowed: make([]stateRootAllowCount, 0, len(s.allowedNodes[hash])),
+		}
+		for _, count := range s.allowedNodes[hash] {
+			file.Allowed = append(file.Allowed, stateRootAllowCount{
+				NodeID:         int64(count.NodeID),
+				RemainingCount: int64(count.RemainingCount),
+				ReservedCount:  int64(count.ReservedCount),
+				ConsumedCount:  int64(count.ConsumedCount),
+				Revoked:        count.Revoked,
+				ValidFrom:      count.ValidFrom,
+				ValidUntil:     count.ValidUntil,
+			})
+		}
+		sort.Slice(file.Allowed, func(i, j int) bool {
+			return file.Allowed[i].NodeID < file.Allowed[j].NodeID
+		})
+		file.Grants = make([]stateRootGrant, 0, len(s.grants[hash]))
+		for _, grant := range s.grants[hash] {
+			file.Grants = append(file.Grants, stateRootGrant{
+				ID:        grant.ID,
+				From:      int64(grant.From),
+				To:        int64(grant.To),
+				Count:     int64(grant.Count),
+				Transfer:  grant.Transfer,
+				Timestamp: grant.Timestamp,
+			})
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return bytes.Compare(files[i].Hash, files[j].Hash) < 0
+	})
+
+	reservations := make([]stateRootReservation, 0, len(s.reservations))
+	for _, reservation := range s.reservations {
+		reservations = append(reservations, stateRootReservation{
+			ID:       reservation.ID,
+			FileHash: append([]byte(nil), reservation.FileHash[:]...),
+			NodeID:   int64(reservation.NodeID),
+			Count:    int64(reservation.Count),
+			Released: int64(reservation.Released),
+			Closed:   reservation.Closed,
+		})
+		if reservation.Receipt != nil {
+			reservations[len(reservations)-1].Receipt = reservation.Receipt.toBytes()
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].ID < reservations[j].ID
+	})
+
+	rawState, err := asn1.Marshal(struct {
+		Time         int64
+		Files        []stateRootFile
+		Reservations []stateRootReservation
+	}{
+		Time:         s.time,
+		Files:        files,
+		Reservations: reservations,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	root := sha3.Sum256(rawState)
+	return root[:]
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+)
+
+// testStateRecord returns a record committed at the given ledger time
+func testStateRecord(timestamp int64, requests ...*Request) *AppRecord {
+	b := batch{}
+	for _, request := range requests {
+		b.Requests = append(b.Requests, request.ToBytes())
+	}
+	return &AppRecord{
+		Batch: &b,
+		Proposal: types.Proposal{
+			Header:  blockHeader{Timestamp: timestamp}.toBytes(),
+			Payload: b.toBytes(),
+		},
+	}
+}
+
+func TestLedgerStateApply(t *testing.T) {
+	state := newLedgerState()
+
+	announce := testAnnounce(t, 2, 3)
+	conflicting := []*Request{
+		testAllow(t, 3, 2, 5, testUnbounded), // not the originating node
+		testAnnounce(t, 2, 3),                // exceeds remaining parts
+		testCancel(t, 2, announce.ID, 4),     // more parts than announced
+		testCancel(t, 2, announce.ID, 0),     // already cancelled
+	}
+
+	for _, tc := range []struct {
+		name   string
+		record *AppRecord
+		time   int64
+		count  AllowCount
+	}{
+		{
+			name:   "allow",
+			record: testStateRecord(1000, testAddFile(t, 1), testAllow(t, 1, 2, 5, testUnbounded), conflicting[0]),
+			time:   1000,
+			count:  AllowCount{NodeID: 2, RemainingCount: 5},
+		},
+		{
+			name:   "announce",
+			record: testStateRecord(1001, announce, conflicting[1]),
+			time:   1001,
+			count:  AllowCount{NodeID: 2, RemainingCount: 2, ReservedCount: 3},
+		},
+		{
+			// Ledger time never moves backwards
+			name:   "cancel",
+			record: testStateRecord(900, conflicting[2], testCancel(t, 2, announce.ID, 1), conflicting[3]),
+			time:   1001,
+			count:  AllowCount{NodeID: 2, RemainingCount: 3, ConsumedCount: 2},
+		},
+		{
+			name:   "adjust",
+			record: testStateRecord(1002, testAdjust(t, 1, 2, 10)),
+			time:   1002,
+			count:  AllowCount{NodeID: 2, RemainingCount: 10, ConsumedCount: 2},
+		},
+		{
+			name:   "revoke",
+			record: testStateRecord(1003, testRevoke(t, 1, 2)),
+			time:   1003,
+			count:  AllowCount{NodeID: 2, ConsumedCount: 2, Revoked: true},
+		},
+		{
+			// Granting again lifts the revocation and replaces the validity window
+			name:   "grant again",
+			record: testStateRecord(1004, testAllow(t, 1, 2, 3, testWindow)),
+			time:   1004,
+			count:  AllowCount{NodeID: 2, RemainingCount: 3, ConsumedCount: 2, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state.apply(tc.record)
+
+			if state.Time() != tc.time {
+				t.Fatalf("Ledger time %d, expected %d", state.Time(), tc.time)
+			}
+			count := state.allowCount(testFile, 2)
+			if count == nil || *count != tc.count {
+				t.Fatalf("Allowance %+v, expected %+v", count, tc.count)
+			}
+		})
+	}
+
+	if owner, ok := state.owner(testFile); !ok || owner != 1 {
+		t.Fatalf("File owned by %v, expected node 1", owner)
+	}
+	if reservation := state.reservations[announce.ID]; !reservation.Closed || reservation.Released != 1 {
+		t.Fatalf("Reservation %+v not closed with one released part", reservation)
+	}
+}
+
+func TestLedgerStateValidity(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		time  int64
+		valid bool
+	}{
+		{"before window", testWindow[0] - 1, false},
+		{"window start", testWindow[0], true},
+		{"within window", testWindow[1] - 1, true},
+		{"window end", testWindow[1], false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newLedgerState()
+			state.apply(testStateRecord(testWindow[0]-100, testAddFile(t, 1), testAllow(t, 1, 2, 5, testWindow)))
+
+			// Validity is evaluated at the ledger time of the batch, not the local clock
+			state.apply(testStateRecord(tc.time, testAnnounce(t, 2, 1)))
+			reserved := state.allowCount(testFile, 2).ReservedCount == 1
+			if reserved != tc.valid {
+				t.Fatalf("Announcement applied %v at ledger time %d, expected %v", reserved, tc.time, tc.valid)
+			}
+		})
+	}
+}
+
+func TestLedgerStateChangeReservedAllowance(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		change   func(announce *Request) []*Request
+		reserved AllowCount // after changing the allowance with 3 of 5 parts reserved
+		closed   AllowCount // after cancelling the reservation with 1 part not produced
+	}{
+		{
+			// Reserved parts stay reserved, they might already be in production
+			name:     "revoke",
+			change:   func(*Request) []*Request { return []*Request{testRevoke(t, 1, 2)} },
+			reserved: AllowCount{NodeID: 2, ReservedCount: 3, Revoked: true},
+			closed:   AllowCount{NodeID: 2, ConsumedCount: 2, Revoked: true},
+		},
+		{
+			name: "revoke with further announcement",
+			change: func(*Request) []*Request {
+				return []*Request{testRevoke(t, 1, 2), testAnnounce(t, 2, 1)}
+			},
+			reserved: AllowCount{NodeID: 2, ReservedCount: 3, Revoked: true},
+			closed:   AllowCount{NodeID: 2, ConsumedCount: 2, Revoked: true},
+		},
+		{
+			// Adjusting sets the remaining parts next to the reserved ones
+			name:     "adjust below reserved",
+			change:   func(*Request) []*Request { return []*Request{testAdjust(t, 1, 2, 1)} },
+			reserved: AllowCount{NodeID: 2, RemainingCount: 1, ReservedCount: 3},
+			closed:   AllowCount{NodeID: 2, RemainingCount: 2, ConsumedCount: 2},
+		},
+		{
+			name:     "adjust to zero",
+			change:   func(*Request) []*Request { return []*Request{testAdjust(t, 1, 2, 0)} },
+			reserved: AllowCount{NodeID: 2, ReservedCount: 3},
+			closed:   AllowCount{NodeID: 2, RemainingCount: 1, ConsumedCount: 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newLedgerState()
+			announce := testAnnounce(t, 2, 3)
+			state.apply(testStateRecord(1000, testAddFile(t, 1), testAllow(t, 1, 2, 5, testUnbounded), announce))
+
+			state.apply(testStateRecord(1000, tc.change(announce)...))
+			if count := state.allowCount(testFile, 2); *count != tc.reserved {
+				t.Fatalf("Allowance %+v after change, expected %+v", *count, tc.reserved)
+			}
+
+			state.apply(testStateRecord(1000, testCancel(t, 2, announce.ID, 1)))
+			if count := state.allowCount(testFile, 2); *count != tc.closed {
+				t.Fatalf("Allowance %+v after cancellation, expected %+v", *count, tc.closed)
+			}
+		})
+	}
+}
+
+func TestLedgerStateReceipts(t *testing.T) {
+	state := newLedgerState()
+
+	announce := testAnnounce(t, 2, 1)
+	state.apply(testStateRecord(1000, testAddFile(t, 1), testAllow(t, 1, 2, 5, testUnbounded), announce, testCancel(t, 2, announce.ID, 0)))
+	root := state.Root()
+
+	// Receipts reference an announcement of the submitting node and are recorded once
+	state.apply(testStateRecord(1000,
+		testReceipt(t, 3, announce.ID),
+		testReceipt(t, 2, testAnnounce(t, 2, 1).ID),
+		testReceipt(t, 2, announce.ID),
+		testReceipt(t, 2, announce.ID),
+	))
+	if bytes.Equal(state.Root(), root) {
+		t.Fatal("State root unchanged by receipt")
+	}
+
+	receipts := state.receipts(1)
+	if len(receipts) != 1 {
+		t.Fatalf("Got %d receipts, expected 1", len(receipts))
+	}
+	if receipts[0].ID != announce.ID || receipts[0].NodeID != 2 || !receipts[0].Receipt.Success {
+		t.Fatalf("Unexpected receipt %+v", receipts[0])
+	}
+	if receipts[0].Receipt.Simulation.Layers != 1 {
+		t.Fatalf("Receipt simulation %+v not recorded", receipts[0].Receipt.Simulation)
+	}
+
+	// Only the originating node of the file lists its receipts
+	if receipts := state.receipts(2); len(receipts) != 0 {
+		t.Fatalf("Got %d receipts for other node, expected none", len(receipts))
+	}
+}
+
+func TestLedgerStateRootDeterministic(t *testing.T) {
+	// Several files and reservations, so map iteration order varies
+	var records []*AppRecord
+	for i := byte(1); i <= 8; i++ {
+		file := FabricationDataHash{i}
+		record := testStateRecord(1000+int64(i),
+			testRequest(t, 1, AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+				FileHash:   file[:],
+				OriginNode: 1,
+				WrappedKey: testKey,
+			}}),
+			testRequest(t, 1, AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+				FileHash:    file[:],
+				AllowedNode: 2,
+				Count:       uint32(i) + 1,
+				WrappedKey:  testKey,
+			}}),
+			testRequest(t, 2, AnnounceFabrication, &TransactionPayload_AnnounceFabrication{AnnounceFabrication: &AnnounceFabricationPayload{
+				FileHash: file[:],
+				Count:    1,
+			}}),
+		)
+		record.Metadata = testRecord(t, uint64(i)).Metadata
+		record.Proposal.Metadata = record.Metadata
+		records = append(records, record)
+	}
+
+	first, second := newLedgerState(), newLedgerState()
+	for _, record := range records {
+		first.apply(record)
+		second.apply(record)
+	}
+
+	root := first.Root()
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(first.Root(), root) || !bytes.Equal(second.Root(), root) {
+			t.Fatal("State root differs for the same records")
+		}
+	}
+
+	// Rebuilding the state from the block store results in the same root
+	path := filepath.Join(t.TempDir(), "ledger")
+	cb := newCommittedBatches(openTestBlockStore(t, path))
+	for _, record := range records {
+		cb.add(record)
+	}
+	cb.store.Close()
+
+	loaded := newCommittedBatches(openTestBlockStore(t, path))
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cb.state.Root(), root) || !bytes.Equal(loaded.state.Root(), root) {
+		t.Fatal("State root differs after loading the ledger")
+	}
+
+	// Any change of the state changes the root
+	for _, tc := range []struct {
+		name   string
+		record *AppRecord
+	}{
+		{"ledger time", testStateRecord(2000)},
+		{"allowance", testStateRecord(1008, testAdjust(t, 1, 2, 100))},
+		{"revocation", testStateRecord(1008, testRevoke(t, 1, 2))},
+		{"reservation", testStateRecord(1008, testAnnounce(t, 2, 1))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newLedgerState()
+			for _, record := range records {
+				state.apply(record)
+			}
+			state.apply(tc.record)
+			if bytes.Equal(state.Root(), root) {
+				t.Fatal("State root unchanged")
+			}
+		})
+	}
+}
+
+func TestLedgerStateTransfer(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		setup    []*Request
+		time     int64
+		transfer *Request
+		sender   AllowCount
+		receiver *AllowCount // nil if the transfer is skipped
+	}{
+		{
+			name:     "new receiver inherits window",
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 3, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+			receiver: &AllowCount{NodeID: 3, RemainingCount: 2, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "receiver with same window",
+			setup:    []*Request{testAllow(t, 1, 3, 1, testWindow)},
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 3, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+			receiver: &AllowCount{NodeID: 3, RemainingCount: 3, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "receiver with other window",
+			setup:    []*Request{testAllow(t, 1, 3, 1, testUnbounded)},
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 5, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "revoked receiver",
+			setup:    []*Request{testAllow(t, 1, 3, 1, testWindow), testRevoke(t, 1, 3)},
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 5, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "exceeding remaining parts",
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 6),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 5, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "revoked sender",
+			setup:    []*Request{testRevoke(t, 1, 2)},
+			time:     1500,
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, Revoked: true, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "expired sender",
+			time:     testWindow[1],
+			transfer: testTransfer(t, 2, 3, 2),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 5, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+		{
+			name:     "sender without allowance",
+			time:     1500,
+			transfer: testTransfer(t, 4, 3, 1),
+			sender:   AllowCount{NodeID: 2, RemainingCount: 5, ValidFrom: testWindow[0], ValidUntil: testWindow[1]},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newLedgerState()
+			setup := append([]*Request{testAddFile(t, 1), testAllow(t, 1, 2, 5, testWindow)}, tc.setup...)
+			state.apply(testStateRecord(testWindow[0], setup...))
+			receiverBefore := state.allowCount(testFile, 3)
+			if receiverBefore != nil {
+				countCopy := *receiverBefore
+				receiverBefore = &countCopy
+			}
+
+			state.apply(testStateRecord(tc.time, tc.transfer))
+
+			if count := state.allowCount(testFile, 2); *count != tc.sender {
+				t.Fatalf("Sender allowance %+v, expected %+v", count, tc.sender)
+			}
+			if tc.receiver == nil {
+				if count := state.allowCount(testFile, 3); count != nil && *count != *receiverBefore {
+					t.Fatalf("Invalid transfer changed receiver allowance to %+v", count)
+				}
+				return
+			}
+			if count := state.allowCount(testFile, 3); count == nil || *count != *tc.receiver {
+				t.Fatalf("Receiver allowance %+v, expected %+v", count, tc.receiver)
+			}
+
+			// Provenance of the receiver leads back to the originating node
+			provenance := state.provenance(testFile, 3)
+			last := provenance[len(provenance)-1]
+			if last.ID != tc.transfer.ID || !last.Transfer || last.From != 2 || last.To != 3 {
+				t.Fatalf("Unexpected provenance %+v", last)
+			}
+			var fromOwner bool
+			for _, grant := range provenance {
+				fromOwner = fromOwner || grant.From == 1 && grant.To == 2
+			}
+			if !fromOwner {
+				t.Fatal("Provenance does not include the grant of the originating node")
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
+	"hash"
 	"io"
 	"sync"
 
@@ -15,10 +17,16 @@ import (
 // Using SHA3-512
 type FabricationDataHash [64]byte
 
-// TODO interface currently assuming data will fit into memory of node, change to streaming interface
+// ErrHashMismatch is returned at the end of stored data which was modified after storing
+var ErrHashMismatch = errors.New("stored data does not match its hash")
+
+// FabricationDataStore stores fabrication data addressed by content hash.
+// Data is streamed, so files need not fit into memory of the node.
 type FabricationDataStore interface {
-	StoreData(payload []byte) (FabricationDataHash, error)
-	GetData(hash FabricationDataHash) ([]byte, error)
+	// StoreData reads payload until EOF and returns its hash
+	StoreData(payload io.Reader) (FabricationDataHash, error)
+	// OpenData returns a reader for stored data, which must be closed by the caller
+	OpenData(hash FabricationDataHash) (io.ReadCloser, error)
 	DeleteData(hash FabricationDataHash) error
 }
 
@@ -48,13 +56,7 @@ func NewVFSStore(baseURL string) *VFSStore {
 	return store
 }
 
-func (vfs *VFSStore) StoreData(payload []byte) (FabricationDataHash, error) {
-	hash := sha3.New512()
-	hash.Write(payload)
-
-	var address FabricationDataHash
-	copy(address[:], hash.Sum(nil))
-
+func (vfs *VFSStore) StoreData(payload io.Reader) (FabricationDataHash, error) {
 	tmpName, err := uuid.GenerateUUID()
 	if err != nil {
 		return FabricationDataHash{}, err
@@ -65,15 +67,23 @@ func (vfs *VFSStore) StoreData(payload []byte) (FabricationDataHash, error) {
 		return FabricationDataHash{}, err
 	}
 
-	_, err = file.Write(payload)
+	// Hash while writing, the content address is known once the payload is stored
+	hash := sha3.New512()
+	_, err = io.Copy(io.MultiWriter(file, hash), payload)
 	if err != nil {
+		file.Close()
+		file.Delete()
 		return FabricationDataHash{}, err
 	}
 	err = file.Close()
 	if err != nil {
+		file.Delete()
 		return FabricationDataHash{}, err
 	}
 
+	var address FabricationDataHash
+	copy(address[:], hash.Sum(nil))
+
 	tmpFile, err := vfs.location.NewFile(tmpName)
 	if err != nil {
 		return FabricationDataHash{}, err
@@ -91,7 +101,7 @@ func (vfs *VFSStore) StoreData(payload []byte) (FabricationDataHash, error) {
 	return address, nil
 }
 
-func (vfs *VFSStore) GetData(address FabricationDataHash) ([]byte, error) {
+func (vfs *VFSStore) OpenData(address FabricationDataHash) (io.ReadCloser, error) {
 
 	file, err := vfs.location.NewFile(hex.EncodeToString(address[:]))
 	if err != nil {
@@ -107,12 +117,28 @@ func (vfs *VFSStore) GetData(address FabricationDataHash) ([]byte, error) {
 		return nil, errors.New("file does not exist")
 	}
 
-	res, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
+	// The backend may be shared or remote, verify the content while it is read
+	return &verifyingReader{ReadCloser: file, hash: sha3.New512(), address: address}, nil
+}
 
-	return res, nil
+// verifyingReader returns ErrHashMismatch instead of EOF if the data read does not match its address
+type verifyingReader struct {
+	io.ReadCloser
+	hash    hash.Hash
+	address FabricationDataHash
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	read, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:read])
+	if err == io.EOF {
+		var received FabricationDataHash
+		copy(received[:], r.hash.Sum(nil))
+		if received != r.address {
+			return read, ErrHashMismatch
+		}
+	}
+	return read, err
 }
 
 func (vfs *VFSStore) DeleteData(address FabricationDataHash) error {
@@ -145,22 +171,26 @@ func NewMemoryStore() *MemoryStore {
 	}
 }
 
-func (mem *MemoryStore) StoreData(payload []byte) (FabricationDataHash, error) {
+// StoreData reads payload into memory, the memory store is meant for testing with small files
+func (mem *MemoryStore) StoreData(payload io.Reader) (FabricationDataHash, error) {
 
 	hash := sha3.New512()
-	hash.Write(payload)
+	data, err := io.ReadAll(io.TeeReader(payload, hash))
+	if err != nil {
+		return FabricationDataHash{}, err
+	}
 
 	var address FabricationDataHash
 	copy(address[:], hash.Sum(nil))
 
 	mem.Lock()
-	mem.store[address] = payload
+	mem.store[address] = data
 	mem.Unlock()
 
 	return address, nil
 }
 
-func (mem *MemoryStore) GetData(address FabricationDataHash) ([]byte, error) {
+func (mem *MemoryStore) OpenData(address FabricationDataHash) (io.ReadCloser, error) {
 	mem.Lock()
 	val, ok := mem.store[address]
 	mem.Unlock()
@@ -169,7 +199,7 @@ func (mem *MemoryStore) GetData(address FabricationDataHash) ([]byte, error) {
 		return nil, errors.New("data does not exist in memory store")
 	}
 
-	return val, nil
+	return io.NopCloser(bytes.NewReader(val)), nil
 }
 
 func (mem *MemoryStore) DeleteData(address FabricationDataHash) error {
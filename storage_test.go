@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// failingReader returns its data followed by an error instead of EOF
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func newTestVFSStore(t *testing.T) *VFSStore {
+	return NewVFSStore("file://" + filepath.ToSlash(t.TempDir()) + "/")
+}
+
+func TestFabricationDataStore(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		store func(t *testing.T) FabricationDataStore
+	}{
+		{"vfs", func(t *testing.T) FabricationDataStore { return newTestVFSStore(t) }},
+		{"memory", func(t *testing.T) FabricationDataStore { return NewMemoryStore() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := tc.store(t)
+			data := bytes.Repeat([]byte("G1 X10 Y10\n"), 10000)
+
+			address, err := store.StoreData(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != FabricationDataHash(sha3.Sum512(data)) {
+				t.Fatal("Address is not the SHA3-512 hash of the content")
+			}
+
+			reader, err := store.OpenData(address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(stored, data) {
+				t.Fatal("Stored data differs from payload")
+			}
+
+			if err := store.DeleteData(address); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.OpenData(address); err == nil {
+				t.Fatal("Opened deleted data")
+			}
+		})
+	}
+}
+
+func TestVFSStorePartialWrite(t *testing.T) {
+	store := newTestVFSStore(t)
+
+	// A payload failing midway leaves neither the temporary file nor a stored address
+	if _, err := store.StoreData(&failingReader{data: []byte("G1 X10")}); err == nil {
+		t.Fatal("Stored failing payload")
+	}
+	files, err := store.location.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("Files %v left after failed write", files)
+	}
+	if _, err := store.OpenData(FabricationDataHash(sha3.Sum512([]byte("G1 X10")))); err == nil {
+		t.Fatal("Opened partially written data")
+	}
+}
+
+func TestVFSStoreHashMismatch(t *testing.T) {
+	dir := t.TempDir()
+	store := NewVFSStore("file://" + filepath.ToSlash(dir) + "/")
+	data := []byte("G1 X10 Y10\n")
+
+	address, err := store.StoreData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Data modified at rest is read, but fails at the end
+	if err := os.WriteFile(filepath.Join(dir, hex.EncodeToString(address[:])), []byte("G1 X99 Y10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := store.OpenData(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("Got %v, expected %v", err, ErrHashMismatch)
+	}
+}
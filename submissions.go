@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status of a submitted request
+const (
+	TxPending   = "pending"
+	TxCommitted = "committed"
+	TxRejected  = "rejected"
+)
+
+// Submissions are forgotten after this duration, committed requests remain available from the ledger
+const submissionRetention = time.Hour
+
+type submission struct {
+	status    string
+	reason    string
+	sequence  uint64
+	submitted time.Time
+	done      chan struct{} // closed once the request is committed or rejected
+}
+
+// TxStatus is the status of a request submitted through this node
+type TxStatus struct {
+	ID       string
+	Status   string
+	Reason   string // why the request was rejected
+	Sequence uint64 // committing block
+}
+
+// submissionTracker records the status of requests submitted through this node,
+// so clients learn whether a request was ordered or dropped
+type submissionTracker struct {
+	lock        sync.Mutex
+	submissions map[string]*submission
+
+	// Requests not ordered within this duration have been removed from the request pool
+	poolTimeout time.Duration
+}
+
+func newSubmissionTracker(poolTimeout time.Duration) *submissionTracker {
+	return &submissionTracker{
+		submissions: make(map[string]*submission),
+		poolTimeout: poolTimeout,
+	}
+}
+
+// track records the request as pending
+func (t *submissionTracker) track(requestID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	for id, s := range t.submissions {
+		if now.Sub(s.submitted) > submissionRetention {
+			delete(t.submissions, id)
+		}
+	}
+
+	if _, ok := t.submissions[requestID]; ok {
+		return
+	}
+	t.submissions[requestID] = &submission{
+		status:    TxPending,
+		submitted: now,
+		done:      make(chan struct{}),
+	}
+}
+
+// resolve sets the final status of a tracked pending request, untracked requests are ignored
+func (t *submissionTracker) resolve(requestID, status, reason string, sequence uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, ok := t.submissions[requestID]
+	if !ok || s.status != TxPending {
+		return
+	}
+	s.status = status
+	s.reason = reason
+	s.sequence = sequence
+	close(s.done)
+}
+
+func (t *submissionTracker) reject(requestID string, err error) {
+	t.resolve(requestID, TxRejected, err.Error(), 0)
+}
+
+// status returns the status of a tracked request
+func (t *submissionTracker) status(requestID string) (*TxStatus, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, ok := t.submissions[requestID]
+	if !ok {
+		return nil, false
+	}
+
+	status := &TxStatus{
+		ID:       requestID,
+		Status:   s.status,
+		Reason:   s.reason,
+		Sequence: s.sequence,
+	}
+	if s.status == TxPending && time.Since(s.submitted) > t.poolTimeout {
+		status.Status = TxRejected
+		status.Reason = fmt.Sprintf("not ordered within %v, dropped from request pool", t.poolTimeout)
+	}
+	return status, true
+}
+
+// wait blocks until the tracked request is committed or rejected, or the timeout expires
+// Returns ErrCommitTimeout if the request is still pending
+func (t *submissionTracker) wait(requestID string, timeout time.Duration) (*TxStatus, error) {
+	t.lock.Lock()
+	s, ok := t.submissions[requestID]
+	t.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("request %v not submitted through this node", requestID)
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+	}
+
+	status, ok := t.status(requestID)
+	if !ok {
+		return nil, fmt.Errorf("request %v no longer tracked", requestID)
+	}
+	switch status.Status {
+	case TxPending:
+		return status, fmt.Errorf("request %v: %w", requestID, ErrCommitTimeout)
+	case TxRejected:
+		return status, fmt.Errorf("request %v rejected: %s", requestID, status.Reason)
+	}
+	return status, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmissionTracker(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		resolve  func(tracker *submissionTracker, id string)
+		timeout  time.Duration
+		status   string
+		sequence uint64
+		err      bool
+	}{
+		{
+			name:     "committed",
+			resolve:  func(tracker *submissionTracker, id string) { tracker.resolve(id, TxCommitted, "", 7) },
+			timeout:  time.Second,
+			status:   TxCommitted,
+			sequence: 7,
+		},
+		{
+			name:    "rejected",
+			resolve: func(tracker *submissionTracker, id string) { tracker.reject(id, errors.New("conflict")) },
+			timeout: time.Second,
+			status:  TxRejected,
+			err:     true,
+		},
+		{
+			name: "resolved once",
+			resolve: func(tracker *submissionTracker, id string) {
+				tracker.resolve(id, TxCommitted, "", 7)
+				tracker.reject(id, errors.New("conflict"))
+			},
+			timeout:  time.Second,
+			status:   TxCommitted,
+			sequence: 7,
+		},
+		{
+			name: "committed while waiting",
+			resolve: func(tracker *submissionTracker, id string) {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					tracker.resolve(id, TxCommitted, "", 8)
+				}()
+			},
+			timeout:  time.Minute,
+			status:   TxCommitted,
+			sequence: 8,
+		},
+		{
+			name:    "pending",
+			resolve: func(tracker *submissionTracker, id string) {},
+			timeout: 10 * time.Millisecond,
+			status:  TxPending,
+			err:     true,
+		},
+		{
+			name: "tracked again",
+			resolve: func(tracker *submissionTracker, id string) {
+				tracker.resolve(id, TxCommitted, "", 7)
+				tracker.track(id)
+			},
+			timeout:  time.Second,
+			status:   TxCommitted,
+			sequence: 7,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := newSubmissionTracker(time.Minute)
+			tracker.track("request-1")
+			tc.resolve(tracker, "request-1")
+
+			status, err := tracker.wait("request-1", tc.timeout)
+			if tc.err && err == nil {
+				t.Fatal("Expected error")
+			}
+			if !tc.err && err != nil {
+				t.Fatal(err)
+			}
+			if tc.status == TxPending && !errors.Is(err, ErrCommitTimeout) {
+				t.Fatalf("Expected ErrCommitTimeout, got %v", err)
+			}
+			if status.Status != tc.status || status.Sequence != tc.sequence {
+				t.Fatalf("Status %+v, expected %v at sequence %d", status, tc.status, tc.sequence)
+			}
+		})
+	}
+}
+
+func TestSubmissionTrackerPoolTimeout(t *testing.T) {
+	tracker := newSubmissionTracker(10 * time.Millisecond)
+	tracker.track("request-1")
+
+	if status, _ := tracker.status("request-1"); status.Status != TxPending {
+		t.Fatalf("Status %v, expected pending", status.Status)
+	}
+
+	// Requests not ordered within the pool timeout were dropped from the request pool
+	time.Sleep(20 * time.Millisecond)
+	status, err := tracker.wait("request-1", time.Millisecond)
+	if err == nil || status.Status != TxRejected {
+		t.Fatalf("Status %+v, expected rejected", status)
+	}
+
+	if _, ok := tracker.status("request-2"); ok {
+		t.Fatal("Untracked request has a status")
+	}
+	if _, err := tracker.wait("request-2", time.Millisecond); err == nil {
+		t.Fatal("Waited for untracked request")
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	syncHeightTimeout = 2 * time.Second
+	syncBlockTimeout  = 5 * time.Second
+	syncMaxAttempts   = 5
+	syncBackoffBase   = 250 * time.Millisecond
+
+	// Penalties are added to a peer score, peers with lower scores are preferred
+	penaltyUnavailable = 1
+	penaltyBadData     = 10
+)
+
+// errBadSyncData marks errors caused by a peer serving invalid blocks
+var errBadSyncData = errors.New("peer served invalid block")
+
+// synchronizer fetches missing blocks from the peer with the best known height,
+// failing over to other peers and penalising peers which serve invalid data
+type synchronizer struct {
+	app *App
+
+	// Peers are dropped if the next block does not arrive in time
+	blockTimeout time.Duration
+
+	lock      sync.Mutex
+	penalties map[NodeID]int
+}
+
+type syncCandidate struct {
+	id     NodeID
+	client NodeExchangeClient
+	height uint64
+}
+
+func newSynchronizer(app *App) *synchronizer {
+	return &synchronizer{
+		app:          app,
+		blockTimeout: syncBlockTimeout,
+		penalties:    make(map[NodeID]int),
+	}
+}
+
+// Sync synchronizes and returns the latest decision
+func (s *synchronizer) Sync() types.SyncResponse {
+	a := s.app
+	reconfigSync := types.ReconfigSync{InReplicatedDecisions: false}
+
+	backoff := syncBackoffBase
+	for attempt := 0; attempt < syncMaxAttempts; attempt++ {
+		candidates := s.candidates()
+		if len(candidates) == 0 {
+			// Single node started or all peers are behind us
+			break
+		}
+
+		candidate := candidates[0]
+		a.logger.Debugf("Sync connection with node %v at height %d", candidate.id, candidate.height)
+
+		synced, err := s.fetch(candidate, &reconfigSync)
+		if err == nil && synced >= candidate.height {
+			s.reward(candidate.id)
+			break
+		}
+
+		if errors.Is(err, errBadSyncData) {
+			s.penalise(candidate.id, penaltyBadData)
+		} else {
+			s.penalise(candidate.id, penaltyUnavailable)
+		}
+		a.logger.Warnf("Sync with node %v failed (attempt %d): %v", candidate.id, attempt+1, err)
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return types.SyncResponse{Latest: *a.lastDecision, Reconfig: reconfigSync}
+}
+
+// candidates queries all connected peers for their height and returns those ahead of us,
+// ordered by penalty score and height
+func (s *synchronizer) candidates() []*syncCandidate {
+	a := s.app
+
+	a.Node.Lock()
+	clients := make(map[NodeID]NodeExchangeClient)
+	for id, client := range a.Node.nodeExchanges {
+		if id == a.ID {
+			continue
+		}
+		clients[id] = client
+	}
+	a.Node.Unlock()
+
+	own := s.height()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var candidates []*syncCandidate
+
+	for id, client := range clients {
+		wg.Add(1)
+		go func(id NodeID, client NodeExchangeClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), syncHeightTimeout)
+			defer cancel()
+
+			pos, err := client.BlockHeight(ctx, &emptypb.Empty{})
+			if err != nil {
+				a.logger.Debugf("Failed to query height of node %v: %v", id, err)
+				s.penalise(id, penaltyUnavailable)
+				return
+			}
+			if pos.LatestSequence <= own {
+				return
+			}
+
+			lock.Lock()
+			candidates = append(candidates, &syncCandidate{id: id, client: client, height: pos.LatestSequence})
+			lock.Unlock()
+		}(id, client)
+	}
+	wg.Wait()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := s.penalties[candidates[i].id], s.penalties[candidates[j].id]
+		if pi != pj {
+			return pi < pj
+		}
+		return candidates[i].height > candidates[j].height
+	})
+
+	return candidates
+}
+
+// fetch streams blocks following our chain tip from the given candidate and delivers them.
+// Returns the latest sequence after fetching.
+func (s *synchronizer) fetch(candidate *syncCandidate, reconfigSync *types.ReconfigSync) (uint64, error) {
+	a := s.app
+
+	// The deadline of the peer moves with every block it serves, so stalled peers are dropped
+	// without limiting how long catching up on a long history may take
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deadline := time.AfterFunc(s.blockTimeout, cancel)
+	defer deadline.Stop()
+
+	// Fetch blocks following our chain tip
+	a.Node.cb.lock.RLock()
+	fetchFrom := &BlockPosition{
+		ViewId:         a.Node.cb.latestMD.GetViewId(),
+		LatestSequence: a.Node.cb.latestMD.GetLatestSequence(),
+	}
+	a.Node.cb.lock.RUnlock()
+
+	stream, err := candidate.client.FetchBlocks(ctx, fetchFrom)
+	if err != nil {
+		return s.height(), err
+	}
+
+	for {
+		deadline.Reset(s.blockTimeout)
+		record, err := stream.Recv()
+		if err == io.EOF {
+			return s.height(), nil
+		}
+		if err != nil {
+			return s.height(), err
+		}
+
+		proposal, signatures, err := s.verify(record)
+		if err != nil {
+			return s.height(), fmt.Errorf("%w: %v", errBadSyncData, err)
+		}
+
+		a.Deliver(proposal, signatures)
+		for _, req := range record.Batch {
+			request := requestFromBytes(req)
+			if request.isReconfig() {
+				reconfig := request.Reconfig.recconfigToUint(a.ID)
+				*reconfigSync = types.ReconfigSync{
+					InReplicatedDecisions: true,
+					CurrentNodes:          reconfig.CurrentNodes,
+					CurrentConfig:         reconfig.CurrentConfig,
+				}
+			}
+		}
+	}
+}
+
+// verify checks that a fetched block extends our chain and was decided by a quorum of consenters
+func (s *synchronizer) verify(record *BlockRecord) (types.Proposal, []types.Signature, error) {
+	a := s.app
+
+	// Refuse history which does not extend our chain
+	if tip := a.Node.cb.tipHash(); !bytes.Equal(record.PrevHash, tip) {
+		return types.Proposal{}, nil, fmt.Errorf("parent hash %x does not match tip %x", record.PrevHash, tip)
+	}
+
+	md := &smartbftprotos.ViewMetadata{}
+	if err := proto.Unmarshal(record.Metadata, md); err != nil {
+		return types.Proposal{}, nil, err
+	}
+	if md.LatestSequence <= s.height() {
+		return types.Proposal{}, nil, fmt.Errorf("unexpected sequence %d", md.LatestSequence)
+	}
+
+	proposal := types.Proposal{
+		Payload:              batch{Requests: record.Batch}.toBytes(),
+		Header:               record.Header,
+		Metadata:             record.Metadata,
+		VerificationSequence: record.VerificationSequence,
+	}
+
+	var signatures []types.Signature
+	for _, sig := range record.Signatures {
+		signatures = append(signatures, types.Signature{
+			ID:    sig.Id,
+			Value: sig.Value,
+			Msg:   sig.Msg,
+		})
+	}
+
+	// Refuse blocks which were not decided by a quorum of consenters
+	if err := a.verifyQuorum(proposal, signatures); err != nil {
+		return types.Proposal{}, nil, err
+	}
+
+	return proposal, signatures, nil
+}
+
+// height returns the latest committed sequence
+func (s *synchronizer) height() uint64 {
+	s.app.Node.cb.lock.RLock()
+	defer s.app.Node.cb.lock.RUnlock()
+	return s.app.Node.cb.latestMD.GetLatestSequence()
+}
+
+func (s *synchronizer) penalise(id NodeID, penalty int) {
+	s.lock.Lock()
+	s.penalties[id] += penalty
+	s.lock.Unlock()
+}
+
+func (s *synchronizer) reward(id NodeID) {
+	s.lock.Lock()
+	if s.penalties[id] > 0 {
+		s.penalties[id]--
+	}
+	s.lock.Unlock()
+}
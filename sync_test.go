@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/SmartBFT-Go/consensus/v2/pkg/types"
+	"github.com/SmartBFT-Go/consensus/v2/smartbftprotos"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeExchange serves the committed records of another node
+type fakeExchange struct {
+	NodeExchangeClient // not implemented methods panic
+
+	records []*BlockRecord
+	err     error // returned by all calls if set
+	stall   bool  // block after serving the records until the stream is cancelled
+	fetches int
+}
+
+func (f *fakeExchange) BlockHeight(ctx context.Context, _ *emptypb.Empty, _ ...grpc.CallOption) (*BlockPosition, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &BlockPosition{LatestSequence: uint64(len(f.records))}, nil
+}
+
+func (f *fakeExchange) FetchBlocks(ctx context.Context, pos *BlockPosition, _ ...grpc.CallOption) (NodeExchange_FetchBlocksClient, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	// Records are numbered from sequence 1
+	return &fakeBlockStream{ctx: ctx, records: f.records[pos.LatestSequence:], stall: f.stall}, nil
+}
+
+type fakeBlockStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	records []*BlockRecord
+	stall   bool
+}
+
+func (s *fakeBlockStream) Recv() (*BlockRecord, error) {
+	if len(s.records) == 0 && s.stall {
+		<-s.ctx.Done()
+		return nil, s.ctx.Err()
+	}
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+// blockRecords returns the committed records of the app as served by FetchBlocks
+func blockRecords(a *App) []*BlockRecord {
+	var records []*BlockRecord
+	for _, record := range a.Node.cb.records {
+		blockRecord := &BlockRecord{
+			Metadata:             record.Metadata,
+			Batch:                record.Batch.Requests,
+			PrevHash:             record.PrevHash,
+			Header:               record.Proposal.Header,
+			VerificationSequence: record.Proposal.VerificationSequence,
+		}
+		for _, sig := range record.Signatures {
+			blockRecord.Signatures = append(blockRecord.Signatures, &ConsenterSignature{
+				Id:    sig.ID,
+				Value: sig.Value,
+				Msg:   sig.Msg,
+			})
+		}
+		records = append(records, blockRecord)
+	}
+	return records
+}
+
+// newTestNetwork returns apps for four consenters, the first of which committed the given number of decisions
+func newTestNetwork(t *testing.T, decisions int) []*App {
+	ca := newTestCA(t)
+	nodes := []NodeID{1, 2, 3, 4}
+
+	var apps []*App
+	for _, id := range nodes {
+		apps = append(apps, newTestApp(t, ca, id, nodes...))
+	}
+
+	for i := 1; i <= decisions; i++ {
+		apps[0].Deliver(testDecision(t, uint64(i), apps[:3], testReservedRequest(i)))
+	}
+	return apps
+}
+
+func TestSyncVerifyChain(t *testing.T) {
+	apps := newTestNetwork(t, 2)
+	source, syncing := apps[0], apps[3]
+
+	for _, tc := range []struct {
+		name   string
+		record func(records []*BlockRecord) *BlockRecord
+		valid  bool
+	}{
+		{
+			name:   "first record",
+			record: func(records []*BlockRecord) *BlockRecord { return records[0] },
+			valid:  true,
+		},
+		{
+			name:   "record not extending tip",
+			record: func(records []*BlockRecord) *BlockRecord { return records[1] },
+		},
+		{
+			name: "first record with parent",
+			record: func(records []*BlockRecord) *BlockRecord {
+				records[0].PrevHash = bytes.Repeat([]byte{1}, 32)
+				return records[0]
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := syncing.syncer.verify(tc.record(blockRecords(source)))
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Verified record not extending the chain")
+			}
+		})
+	}
+
+	// Synced records extend the chain of the syncing node
+	synced, err := syncing.syncer.fetch(&syncCandidate{id: source.ID, client: &fakeExchange{records: blockRecords(source)}, height: 2}, &types.ReconfigSync{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced != 2 {
+		t.Fatalf("Synced to %d, expected 2", synced)
+	}
+	if !bytes.Equal(syncing.Node.cb.tipHash(), source.Node.cb.tipHash()) {
+		t.Fatal("Synced chain tip does not match")
+	}
+
+	// The parent hash is not covered by consenter signatures, only the chain check refuses forked history
+	tampered := blockRecords(source)
+	tampered[1].PrevHash = bytes.Repeat([]byte{1}, 32)
+	synced, err = apps[2].syncer.fetch(&syncCandidate{id: source.ID, client: &fakeExchange{records: tampered}, height: 2}, &types.ReconfigSync{})
+	if !errors.Is(err, errBadSyncData) {
+		t.Fatalf("Expected errBadSyncData, got %v", err)
+	}
+	if synced != 1 {
+		t.Fatalf("Synced to %d, expected 1", synced)
+	}
+}
+
+func TestSyncFailover(t *testing.T) {
+	apps := newTestNetwork(t, 3)
+	source, syncing := apps[0], apps[3]
+
+	// The peer announcing the greatest height is tried first, but serves blocks without quorum
+	invalid := blockRecords(source)
+	invalid[0].Signatures = invalid[0].Signatures[:2]
+
+	unavailable := &fakeExchange{err: errors.New("unavailable")}
+	bad := &fakeExchange{records: invalid}
+	good := &fakeExchange{records: blockRecords(source)[:2]}
+	syncing.Node.nodeExchanges = map[NodeID]NodeExchangeClient{1: unavailable, 2: bad, 3: good}
+
+	response := syncing.syncer.Sync()
+
+	md := &smartbftprotos.ViewMetadata{}
+	if err := proto.Unmarshal(response.Latest.Proposal.Metadata, md); err != nil {
+		t.Fatal(err)
+	}
+	if md.LatestSequence != 2 {
+		t.Fatalf("Synced to %d, expected 2", md.LatestSequence)
+	}
+	if !bytes.Equal(syncing.Node.cb.tipHash(), source.Node.cb.records[1].Hash()) {
+		t.Fatal("Synced chain tip does not match")
+	}
+
+	if bad.fetches != 1 || good.fetches != 1 {
+		t.Fatalf("Fetched %d times from bad and %d times from good peer, expected once each", bad.fetches, good.fetches)
+	}
+	for _, tc := range []struct {
+		id      NodeID
+		penalty int
+	}{
+		{1, 2 * penaltyUnavailable}, // queried for its height in both attempts
+		{2, penaltyBadData},
+		{3, 0},
+	} {
+		if penalty := syncing.syncer.penalties[tc.id]; penalty != tc.penalty {
+			t.Errorf("Node %v has penalty %d, expected %d", tc.id, penalty, tc.penalty)
+		}
+	}
+}
+
+func TestSyncStalledPeer(t *testing.T) {
+	apps := newTestNetwork(t, 3)
+	source, syncing := apps[0], apps[3]
+	syncing.syncer.blockTimeout = 50 * time.Millisecond
+
+	// The stalled peer serves its blocks, but does not close the stream
+	stalled := &fakeExchange{records: blockRecords(source), stall: true}
+	start := time.Now()
+	synced, err := syncing.syncer.fetch(&syncCandidate{id: source.ID, client: stalled, height: 3}, &types.ReconfigSync{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancelled stream, got %v", err)
+	}
+	if synced != 3 {
+		t.Fatalf("Synced to %d, expected 3", synced)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Stalled peer dropped after %v", elapsed)
+	}
+}
+
+func TestSyncReconfig(t *testing.T) {
+	apps := newTestNetwork(t, 0)
+	source, syncing := apps[0], apps[3]
+
+	// Only requests of the reconfig client change the membership, as in App.Deliver
+	forged := Request{ClientID: "node-1", ID: "request-1", Reconfig: Reconfig{InLatestDecision: true, CurrentNodes: []int64{1}}}
+	reconfig := Request{ClientID: reconfigClientID, ID: "reconfig-1", Reconfig: Reconfig{InLatestDecision: true, CurrentNodes: []int64{1, 2, 3, 4}}}
+	source.Deliver(testDecision(t, 1, apps[:3], forged.ToBytes()))
+
+	reconfigSync := types.ReconfigSync{}
+	if _, err := syncing.syncer.fetch(&syncCandidate{id: source.ID, client: &fakeExchange{records: blockRecords(source)}, height: 1}, &reconfigSync); err != nil {
+		t.Fatal(err)
+	}
+	if reconfigSync.InReplicatedDecisions {
+		t.Fatalf("Client request synced as reconfig to %v", reconfigSync.CurrentNodes)
+	}
+
+	source.Deliver(testDecision(t, 2, apps[:3], reconfig.ToBytes()))
+	if _, err := syncing.syncer.fetch(&syncCandidate{id: source.ID, client: &fakeExchange{records: blockRecords(source)}, height: 2}, &reconfigSync); err != nil {
+		t.Fatal(err)
+	}
+	if !reconfigSync.InReplicatedDecisions || len(reconfigSync.CurrentNodes) != 4 {
+		t.Fatalf("Reconfig synced as %+v", reconfigSync)
+	}
+}
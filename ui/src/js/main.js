@@ -15,6 +15,86 @@ Chart.defaults.backgroundColor = 'transparent';
 Chart.defaults.borderColor = '#6b6b6b';
 Chart.defaults.color = '#fff';
 
+// Authentication
+
+const loginModal = new bootstrap.Modal(document.getElementById('login-modal'));
+
+function showLogin() {
+  document.getElementById("principal-name").innerHTML = "Not signed in";
+  loginModal.show();
+}
+
+function showToast(message) {
+  document.getElementById("toast-body").textContent = message;
+  const toast = new bootstrap.Toast(document.getElementById('liveToast'));
+  toast.show({"autohide": true});
+}
+
+// apiFetch rejects failed requests with the reason returned by the node,
+// asking for a token if the request was not authenticated
+function apiFetch(url, options) {
+  return fetch(url, options)
+  .then(response => {
+    if (response.status === 401) {
+      showLogin();
+      throw new Error("Authentication required");
+    }
+    if (response.status === 403) {
+      // Missing role or a request refused by the ledger, e.g. without allowance
+      return response.text().then(text => { throw new Error("Not permitted: " + text.trim()); });
+    }
+    if (!response.ok) {
+      return response.text().then(text => { throw new Error(text.trim() || response.statusText); });
+    }
+    return response;
+  });
+}
+
+document.getElementById("login-form").addEventListener('submit', (event) => {
+  event.preventDefault();
+
+  let form = document.getElementById("login-form");
+  let token = document.getElementById("loginToken");
+
+  // Sets the session cookie used by all further requests
+  fetch("api/login", {
+    body: new URLSearchParams(new FormData(form)),
+    method: "post",
+  })
+  .then(response => {
+    if (response.status === 401) {
+      token.classList.add("is-invalid");
+      return;
+    }
+    if (!response.ok) {
+      throw new Error(response.statusText);
+    }
+    return response.json().then(principal => {
+      token.classList.remove("is-invalid");
+      form.reset();
+      loginModal.hide();
+      document.getElementById("principal-name").innerHTML = principal.Name;
+      updateStatus();
+    });
+  })
+  .catch((error) => {
+    console.error('Error:', error);
+    showToast("Sign in failed: " + error.message);
+  });
+});
+
+document.getElementById("login-submit").addEventListener('click', (event) => {
+  document.getElementById("login-form").requestSubmit();
+});
+
+document.getElementById("logout").addEventListener('click', (event) => {
+  fetch("api/logout", {method: "post"})
+  .then(showLogin)
+  .catch((error) => {
+    console.error('Error:', error);
+  });
+});
+
 // Fetch node status / id
 
 //	NodeID         NodeID
@@ -33,7 +113,7 @@ let records;
 let totalFiles;
 
 function updateStatus() {
-  fetch('/api/status')
+  apiFetch('/api/status')
   .then(response => response.json())
   .then(json => {
    nodeID = json.NodeID;
@@ -73,37 +153,37 @@ function updateStatus() {
 let updateStatusInterval = setInterval(updateStatus, 1000);
 
 document.getElementById("upload-submit").addEventListener('click', (event) => {
-// TODO error handling
 
 let form = document.getElementById("upload-form");
 
-fetch("api/addfile", {
+// TODO show progress?
+apiFetch("api/addfile", {
     body: new FormData(form),
     method: "post",
+})
+.then(response => {
+  showToast("Upload successful.");
+  form.reset();
+})
+.catch((error) => {
+  console.error('Error:', error);
+  showToast("Upload failed: " + error.message);
 });
 
-// TODO show progress?
-// TODO check result code
-const toastLiveExample = document.getElementById('liveToast');
-const toast = new bootstrap.Toast(toastLiveExample);
-toast.show({"autohide": true});
-
-form.reset();
-
 });
 
 document.getElementById("fabricate-submit").addEventListener('click', (event) => {
-// TODO error handling
-	fetch("api/fabricate", {
+	apiFetch("api/fabricate", {
     body: new FormData(document.getElementById("fabricate-form")),
     method: "post",
+})
+.then(response => response.text())
+.then(text => showToast(text))
+.catch((error) => {
+  console.error('Error:', error);
+  showToast("Fabrication failed: " + error.message);
 });
 
-// TODO check result code
-const toastLiveExample = document.getElementById('liveToast');
-const toast = new bootstrap.Toast(toastLiveExample);
-toast.show({"autohide": true});
-
 });
 
 document.getElementById("fabricate-refresh").addEventListener('click', refreshFabricate);
@@ -136,7 +216,7 @@ function refreshNodes(systemNodes) {
 
 function refreshFabricate() {
 	
-fetch('/api/availabledata')
+apiFetch('/api/availabledata')
  .then(response => response.json())
  .then(json => {
 
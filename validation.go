@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	hashPayloadSize  = len(FabricationDataHash{})
+	clientIDPrefix   = "node-"
+	reconfigClientID = "reconfig"
+
+	// payloadVersion is the current TransactionPayload version
+	payloadVersion = 1
+)
+
+var (
+	ErrInvalidPayload = errors.New("invalid payload")
+	ErrPayloadType    = errors.New("payload does not match request type")
+	ErrInvalidCount   = errors.New("count must be positive")
+	ErrUnknownFile    = errors.New("unknown file")
+	ErrMissingKey     = errors.New("missing wrapped file key")
+	ErrCommitTimeout  = errors.New("timeout waiting for commit")
+
+	// ErrUnsupportedEncoding is returned for requests written by an incompatible version, see README
+	ErrUnsupportedEncoding = errors.New("unsupported request encoding")
+)
+
+// nodeIDFromClientID returns the originating node of a ClientID formatted as node-<id>
+func nodeIDFromClientID(clientID string) (NodeID, error) {
+	if !strings.HasPrefix(clientID, clientIDPrefix) {
+		return 0, fmt.Errorf("invalid client id %q", clientID)
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(clientID, clientIDPrefix), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid client id %q", clientID)
+	}
+	return NodeID(id), nil
+}
+
+// isReconfig reports whether the request changes the consensus membership
+func (txn *Request) isReconfig() bool {
+	return txn.ClientID == reconfigClientID && txn.Reconfig.InLatestDecision
+}
+
+// newPayload returns the encoded TransactionPayload of the current version carrying the given payload
+func newPayload(payload isTransactionPayload_Payload) []byte {
+	rawPayload, err := proto.Marshal(&TransactionPayload{
+		Version: payloadVersion,
+		Payload: payload,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return rawPayload
+}
+
+// decodePayload returns the typed payload of the request, checking version and request type
+func (txn *Request) decodePayload() (*TransactionPayload, error) {
+	payload := &TransactionPayload{}
+	if err := proto.Unmarshal(txn.Payload, payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if payload.Version != payloadVersion {
+		return nil, fmt.Errorf("%w: payload version %d", ErrUnsupportedEncoding, payload.Version)
+	}
+
+	var payloadType RequestType
+	switch payload.Payload.(type) {
+	case *TransactionPayload_AddFile:
+		payloadType = AddFile
+	case *TransactionPayload_AllowFabrication:
+		payloadType = AllowFabrication
+	case *TransactionPayload_AnnounceFabrication:
+		payloadType = AnnounceFabrication
+	case *TransactionPayload_CancelFabrication:
+		payloadType = CancelFabrication
+	case *TransactionPayload_FabricationCompleted:
+		payloadType = FabricationCompleted
+	case *TransactionPayload_RevokeFabrication:
+		payloadType = RevokeFabrication
+	case *TransactionPayload_AdjustFabrication:
+		payloadType = AdjustFabrication
+	case *TransactionPayload_TransferFabrication:
+		payloadType = TransferFabrication
+	default:
+		return nil, ErrPayloadType
+	}
+	if payloadType != txn.Type {
+		return nil, ErrPayloadType
+	}
+
+	return payload, nil
+}
+
+// fileHash returns the file hash of a validated payload
+func fileHash(rawHash []byte) FabricationDataHash {
+	var address FabricationDataHash
+	copy(address[:], rawHash)
+	return address
+}
+
+// fileHash returns the file referenced by the request payload, if any
+func (txn *Request) fileHash() (FabricationDataHash, bool) {
+	if txn.Type == SystemReserved {
+		return FabricationDataHash{}, false
+	}
+	payload, err := txn.decodePayload()
+	if err != nil {
+		return FabricationDataHash{}, false
+	}
+
+	var rawHash []byte
+	switch p := payload.Payload.(type) {
+	case *TransactionPayload_AddFile:
+		rawHash = p.AddFile.GetFileHash()
+	case *TransactionPayload_AllowFabrication:
+		rawHash = p.AllowFabrication.GetFileHash()
+	case *TransactionPayload_AnnounceFabrication:
+		rawHash = p.AnnounceFabrication.GetFileHash()
+	case *TransactionPayload_CancelFabrication:
+		rawHash = p.CancelFabrication.GetFileHash()
+	case *TransactionPayload_FabricationCompleted:
+		rawHash = p.FabricationCompleted.GetFileHash()
+	case *TransactionPayload_RevokeFabrication:
+		rawHash = p.RevokeFabrication.GetFileHash()
+	case *TransactionPayload_AdjustFabrication:
+		rawHash = p.AdjustFabrication.GetFileHash()
+	case *TransactionPayload_TransferFabrication:
+		rawHash = p.TransferFabrication.GetFileHash()
+	}
+	if validateFileHash(rawHash) != nil {
+		return FabricationDataHash{}, false
+	}
+	return fileHash(rawHash), true
+}
+
+func validateFileHash(rawHash []byte) error {
+	if len(rawHash) != hashPayloadSize {
+		return fmt.Errorf("%w: file hash length %d", ErrInvalidPayload, len(rawHash))
+	}
+	return nil
+}
+
+// validateUUID checks that the given ID is a lower case UUID, which is the format generated by nodes
+func validateUUID(id string) error {
+	rawID, err := uuid.ParseUUID(id)
+	if err != nil {
+		return fmt.Errorf("%q is not a UUID", id)
+	}
+	if formattedID, _ := uuid.FormatUUID(rawID); formattedID != id {
+		return fmt.Errorf("%q is not a lower case UUID", id)
+	}
+	return nil
+}
+
+// validate performs stateless checks of the request
+func (txn *Request) validate() error {
+	// Only reconfig requests may change the consensus membership, see App.verifyClientSignature
+	if txn.ClientID == reconfigClientID {
+		if txn.Type != SystemReserved || !txn.Reconfig.InLatestDecision || len(txn.Reconfig.CurrentNodes) == 0 {
+			return errors.New("invalid reconfig request")
+		}
+		return nil
+	}
+	if txn.Reconfig.InLatestDecision || len(txn.Reconfig.CurrentNodes) > 0 {
+		return errors.New("unexpected reconfig in client request")
+	}
+
+	if txn.Type == SystemReserved {
+		return nil
+	}
+
+	submitter, err := nodeIDFromClientID(txn.ClientID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := txn.decodePayload()
+	if err != nil {
+		return err
+	}
+
+	switch txn.Type {
+	case AddFile:
+		p := payload.GetAddFile()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if NodeID(p.OriginNode) != submitter {
+			return errors.New("originating node does not match submitter")
+		}
+		if len(p.WrappedKey) == 0 {
+			return ErrMissingKey
+		}
+
+	case AllowFabrication:
+		p := payload.GetAllowFabrication()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if p.Count == 0 {
+			return ErrInvalidCount
+		}
+		if p.ValidFrom < 0 || p.ValidUntil < 0 {
+			return errors.New("validity must not be negative")
+		}
+		if p.ValidUntil != 0 && p.ValidUntil <= p.ValidFrom {
+			return errors.New("validity window is empty")
+		}
+		if len(p.WrappedKey) == 0 {
+			return ErrMissingKey
+		}
+
+	case RevokeFabrication:
+		if err := validateFileHash(payload.GetRevokeFabrication().FileHash); err != nil {
+			return err
+		}
+
+	case AdjustFabrication:
+		if err := validateFileHash(payload.GetAdjustFabrication().FileHash); err != nil {
+			return err
+		}
+
+	case TransferFabrication:
+		p := payload.GetTransferFabrication()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if p.Count == 0 {
+			return ErrInvalidCount
+		}
+		if NodeID(p.ReceivingNode) == submitter {
+			return errors.New("cannot transfer allowance to submitter")
+		}
+		if len(p.WrappedKey) == 0 {
+			return ErrMissingKey
+		}
+
+	case AnnounceFabrication:
+		p := payload.GetAnnounceFabrication()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if p.Count == 0 {
+			return ErrInvalidCount
+		}
+		// Cancellations reference the announcement by its ID
+		if err := validateUUID(txn.ID); err != nil {
+			return fmt.Errorf("announcement id: %w", err)
+		}
+
+	case CancelFabrication:
+		p := payload.GetCancelFabrication()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if err := validateUUID(p.AnnounceId); err != nil {
+			return fmt.Errorf("announcement id: %w", err)
+		}
+
+	case FabricationCompleted:
+		p := payload.GetFabricationCompleted()
+		if err := validateFileHash(p.FileHash); err != nil {
+			return err
+		}
+		if p.CommandsSent < 0 {
+			return errors.New("commands sent must not be negative")
+		}
+		receipt := receiptFromPayload(p)
+		if !bytes.Equal(receipt.Digest, receipt.Simulation.Digest()) {
+			return errors.New("simulation digest mismatch")
+		}
+
+	default:
+		return fmt.Errorf("unknown request type %d", txn.Type)
+	}
+
+	return nil
+}
+
+// requestValidator checks requests against a copy-on-write overlay of the aggregated ledger state
+// before they enter the request pool. Validated requests are applied to the overlay,
+// so later requests are checked including the effects of earlier ones.
+// Committed requests are checked again when they are applied, see ledgerState.apply.
+type requestValidator struct {
+	committed *ledgerState
+	state     *ledgerState // overlay, created on first use
+}
+
+func newRequestValidator(cb *committedBatches) *requestValidator {
+	return &requestValidator{
+		committed: cb.state,
+	}
+}
+
+// validate checks the request against ledger state and records its effects on success.
+// If allowPending is set, references to files which are unknown so far are accepted,
+// since they might be added by requests which were not ordered yet.
+func (v *requestValidator) validate(txn *Request, allowPending bool) error {
+	if err := txn.validate(); err != nil {
+		return err
+	}
+
+	// The overlay reads through to the committed state
+	v.committed.RLock()
+	defer v.committed.RUnlock()
+	if v.state == nil {
+		v.state = v.committed.overlay()
+	}
+
+	err := v.state.check(txn)
+	if errors.Is(err, ErrUnknownFile) && allowPending {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v.state.applyRequest(txn)
+	return nil
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fabian-z/fabrico-ledger/gcodesim"
+	"github.com/hashicorp/go-uuid"
+)
+
+var (
+	testFile      = FabricationDataHash{1}
+	testWindow    = [2]int64{1000, 2000} // ledger time
+	testUnbounded = [2]int64{}
+	testKey       = []byte("wrapped key")
+)
+
+func testRequest(t *testing.T, node NodeID, requestType RequestType, payload isTransactionPayload_Payload) *Request {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Request{
+		ClientID: fmt.Sprintf("node-%v", node),
+		ID:       id,
+		Type:     requestType,
+		Payload:  newPayload(payload),
+	}
+}
+
+func testAddFile(t *testing.T, owner NodeID) *Request {
+	return testRequest(t, owner, AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+		FileHash:   testFile[:],
+		OriginNode: uint64(owner),
+		WrappedKey: testKey,
+	}})
+}
+
+func testAllow(t *testing.T, owner, node NodeID, count uint32, window [2]int64) *Request {
+	return testRequest(t, owner, AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+		FileHash:    testFile[:],
+		AllowedNode: uint64(node),
+		Count:       count,
+		ValidFrom:   window[0],
+		ValidUntil:  window[1],
+		WrappedKey:  testKey,
+	}})
+}
+
+func testTransfer(t *testing.T, from, to NodeID, count uint32) *Request {
+	return testRequest(t, from, TransferFabrication, &TransactionPayload_TransferFabrication{TransferFabrication: &TransferFabricationPayload{
+		FileHash:      testFile[:],
+		ReceivingNode: uint64(to),
+		Count:         count,
+		WrappedKey:    testKey,
+	}})
+}
+
+func testAnnounce(t *testing.T, node NodeID, count uint32) *Request {
+	return testRequest(t, node, AnnounceFabrication, &TransactionPayload_AnnounceFabrication{AnnounceFabrication: &AnnounceFabricationPayload{
+		FileHash: testFile[:],
+		Count:    count,
+	}})
+}
+
+func testCancel(t *testing.T, node NodeID, announceID string, notProduced uint32) *Request {
+	return testRequest(t, node, CancelFabrication, &TransactionPayload_CancelFabrication{CancelFabrication: &CancelFabricationPayload{
+		FileHash:    testFile[:],
+		AnnounceId:  announceID,
+		NotProduced: notProduced,
+	}})
+}
+
+func testRevoke(t *testing.T, owner, node NodeID) *Request {
+	return testRequest(t, owner, RevokeFabrication, &TransactionPayload_RevokeFabrication{RevokeFabrication: &RevokeFabricationPayload{
+		FileHash:    testFile[:],
+		AllowedNode: uint64(node),
+	}})
+}
+
+func testAdjust(t *testing.T, owner, node NodeID, remaining uint32) *Request {
+	return testRequest(t, owner, AdjustFabrication, &TransactionPayload_AdjustFabrication{AdjustFabrication: &AdjustFabricationPayload{
+		FileHash:    testFile[:],
+		AllowedNode: uint64(node),
+		Remaining:   remaining,
+	}})
+}
+
+// testProgram extrudes a 10mm x 5mm rectangle outline in a single layer
+const testProgram = `G28
+G1 Z0.2 F3000
+G1 X10 Y0 E1
+G1 X10 Y5 E1.5
+G1 X0 Y5 E2.5
+G1 X0 Y0 E3
+`
+
+// testReceipt returns a receipt request for the announcement, simulating the test program
+func testReceipt(t *testing.T, node NodeID, announceID string) *Request {
+	printer, err := gcodesim.Simulate(strings.NewReader(testProgram))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := FabricationReceipt{
+		FileHash:     testFile[:],
+		AnnounceID:   announceID,
+		Success:      true,
+		CommandsSent: int64(strings.Count(testProgram, "\n")),
+		Simulation:   newSimulationSummary(printer),
+	}
+	receipt.Digest = receipt.Simulation.Digest()
+	return testRequest(t, node, FabricationCompleted, &TransactionPayload_FabricationCompleted{
+		FabricationCompleted: receipt.toPayload(),
+	})
+}
+
+// testReceiptOf returns the receipt carried by the request
+func testReceiptOf(t *testing.T, req *Request) *FabricationReceipt {
+	payload, err := req.decodePayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return receiptFromPayload(payload.GetFabricationCompleted())
+}
+
+func TestReceiptSimulation(t *testing.T) {
+	receipt := testReceiptOf(t, testReceipt(t, 2, "announce-1"))
+
+	expected := SimulationSummary{
+		Simulated:        true,
+		Layers:           1,
+		ExtrudedFilament: 3000,
+		MinZ:             200,
+		MaxX:             10000,
+		MaxY:             5000,
+		MaxZ:             200,
+	}
+	if receipt.Simulation != expected {
+		t.Fatalf("Simulation summary %+v, expected %+v", receipt.Simulation, expected)
+	}
+	if receipt.AnnounceID != "announce-1" || receipt.CommandsSent != 6 {
+		t.Fatalf("Receipt %+v does not match job", receipt)
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		request func() *Request
+		invalid bool
+		err     error // expected error if set
+	}{
+		{
+			name:    "add file",
+			request: func() *Request { return testAddFile(t, 1) },
+		},
+		{
+			name: "add file for other node",
+			request: func() *Request {
+				req := testAddFile(t, 1)
+				req.ClientID = "node-2"
+				return req
+			},
+			invalid: true,
+		},
+		{
+			name: "add file without key",
+			request: func() *Request {
+				return testRequest(t, 1, AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+					FileHash:   testFile[:],
+					OriginNode: 1,
+				}})
+			},
+			err: ErrMissingKey,
+		},
+		{
+			name: "allow without key",
+			request: func() *Request {
+				return testRequest(t, 1, AllowFabrication, &TransactionPayload_AllowFabrication{AllowFabrication: &AllowFabricationPayload{
+					FileHash:    testFile[:],
+					AllowedNode: 2,
+					Count:       1,
+				}})
+			},
+			err: ErrMissingKey,
+		},
+		{
+			name: "transfer without key",
+			request: func() *Request {
+				return testRequest(t, 2, TransferFabrication, &TransactionPayload_TransferFabrication{TransferFabrication: &TransferFabricationPayload{
+					FileHash:      testFile[:],
+					ReceivingNode: 3,
+					Count:         1,
+				}})
+			},
+			err: ErrMissingKey,
+		},
+		{
+			name: "short file hash",
+			request: func() *Request {
+				return testRequest(t, 1, AddFile, &TransactionPayload_AddFile{AddFile: &AddFilePayload{
+					FileHash:   testFile[:8],
+					OriginNode: 1,
+					WrappedKey: testKey,
+				}})
+			},
+			err: ErrInvalidPayload,
+		},
+		{
+			name: "payload of other type",
+			request: func() *Request {
+				req := testAllow(t, 1, 2, 1, testUnbounded)
+				req.Type = TransferFabrication
+				return req
+			},
+			err: ErrPayloadType,
+		},
+		{
+			name: "undecodable payload",
+			request: func() *Request {
+				req := testAddFile(t, 1)
+				req.Payload = []byte{0xff}
+				return req
+			},
+			err: ErrInvalidPayload,
+		},
+		{
+			name:    "allow",
+			request: func() *Request { return testAllow(t, 1, 2, 1, testUnbounded) },
+		},
+		{
+			name:    "allow with window",
+			request: func() *Request { return testAllow(t, 1, 2, 1, testWindow) },
+		},
+		{
+			name:    "allow empty window",
+			request: func() *Request { return testAllow(t, 1, 2, 1, [2]int64{2000, 1000}) },
+			invalid: true,
+		},
+		{
+			name:    "allow negative window",
+			request: func() *Request { return testAllow(t, 1, 2, 1, [2]int64{-1, 1000}) },
+			invalid: true,
+		},
+		{
+			name:    "allow zero parts",
+			request: func() *Request { return testAllow(t, 1, 2, 0, testUnbounded) },
+			err:     ErrInvalidCount,
+		},
+		{
+			name:    "transfer",
+			request: func() *Request { return testTransfer(t, 2, 3, 1) },
+		},
+		{
+			name:    "transfer to self",
+			request: func() *Request { return testTransfer(t, 2, 2, 1) },
+			invalid: true,
+		},
+		{
+			name:    "transfer zero parts",
+			request: func() *Request { return testTransfer(t, 2, 3, 0) },
+			err:     ErrInvalidCount,
+		},
+		{
+			name: "announce without UUID",
+			request: func() *Request {
+				req := testAnnounce(t, 2, 1)
+				req.ID = "announce-1"
+				return req
+			},
+			invalid: true,
+		},
+		{
+			name:    "announce zero parts",
+			request: func() *Request { return testAnnounce(t, 2, 0) },
+			err:     ErrInvalidCount,
+		},
+		{
+			name:    "cancel without announce ID",
+			request: func() *Request { return testCancel(t, 2, "", 0) },
+			invalid: true,
+		},
+		{
+			name:    "adjust to zero parts",
+			request: func() *Request { return testAdjust(t, 1, 2, 0) },
+		},
+		{
+			name: "revoke without file hash",
+			request: func() *Request {
+				return testRequest(t, 1, RevokeFabrication, &TransactionPayload_RevokeFabrication{RevokeFabrication: &RevokeFabricationPayload{
+					AllowedNode: 2,
+				}})
+			},
+			err: ErrInvalidPayload,
+		},
+		{
+			name:    "receipt",
+			request: func() *Request { return testReceipt(t, 2, testAnnounce(t, 2, 1).ID) },
+		},
+		{
+			name: "receipt with simulation digest mismatch",
+			request: func() *Request {
+				req := testReceipt(t, 2, testAnnounce(t, 2, 1).ID)
+				receipt := testReceiptOf(t, req)
+				receipt.Simulation.Layers++
+				req.Payload = newPayload(&TransactionPayload_FabricationCompleted{
+					FabricationCompleted: receipt.toPayload(),
+				})
+				return req
+			},
+			invalid: true,
+		},
+		{
+			name:    "unknown type",
+			request: func() *Request { return testRequest(t, 1, RequestType(100), nil) },
+			invalid: true,
+		},
+		{
+			name: "invalid client",
+			request: func() *Request {
+				req := testAddFile(t, 1)
+				req.ClientID = "client-1"
+				return req
+			},
+			invalid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request().validate()
+			invalid := tc.invalid || tc.err != nil
+			if !invalid && err != nil {
+				t.Fatal(err)
+			}
+			if invalid && err == nil {
+				t.Fatal("Validated invalid request")
+			}
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("Expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateReconfig(t *testing.T) {
+	reconfig := Reconfig{InLatestDecision: true, CurrentNodes: []int64{1, 2, 3, 4, 5}}
+
+	for _, tc := range []struct {
+		name    string
+		request Request
+		valid   bool
+	}{
+		{"reconfig", Request{ClientID: reconfigClientID, ID: "reconfig-1", Type: SystemReserved, Reconfig: reconfig}, true},
+		{"reconfig without nodes", Request{ClientID: reconfigClientID, ID: "reconfig-1", Type: SystemReserved, Reconfig: Reconfig{InLatestDecision: true}}, false},
+		{"reconfig client without reconfig", Request{ClientID: reconfigClientID, ID: "reconfig-1", Type: SystemReserved}, false},
+		{"reconfig client with payload type", Request{ClientID: reconfigClientID, ID: "reconfig-1", Type: AddFile, Reconfig: reconfig}, false},
+		{"client with reconfig", Request{ClientID: "node-1", ID: "request-1", Type: SystemReserved, Reconfig: reconfig}, false},
+		{"client with reconfig nodes", Request{ClientID: "node-1", ID: "request-1", Type: SystemReserved, Reconfig: Reconfig{CurrentNodes: []int64{1}}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.validate()
+			if tc.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("Validated invalid request")
+			}
+		})
+	}
+}
+
+func TestRequestValidator(t *testing.T) {
+	cb := newCommittedBatches(nil)
+
+	// Later requests are checked including the effects of earlier ones
+	validator := newRequestValidator(cb)
+	for _, tc := range []struct {
+		name         string
+		request      *Request
+		allowPending bool
+		conflicting  bool
+	}{
+		{"allow pending file", testAllow(t, 1, 2, 5, testUnbounded), true, false},
+		{"allow unknown file", testAllow(t, 1, 2, 5, testUnbounded), false, true},
+		{"add file", testAddFile(t, 1), false, false},
+		{"add file twice", testAddFile(t, 2), false, true},
+		{"allow by other node", testAllow(t, 2, 3, 5, testUnbounded), false, true},
+		{"allow", testAllow(t, 1, 2, 5, testUnbounded), false, false},
+		{"announce", testAnnounce(t, 2, 3), false, false},
+		{"announce exceeding remaining parts", testAnnounce(t, 2, 3), false, true},
+		{"announce without allowance", testAnnounce(t, 3, 1), false, true},
+		{"revoke by other node", testRevoke(t, 2, 2), false, true},
+		{"revoke missing allowance", testRevoke(t, 1, 3), false, true},
+		{"revoke", testRevoke(t, 1, 2), false, false},
+		{"adjust revoked allowance", testAdjust(t, 1, 2, 5), false, true},
+		{"announce on revoked allowance", testAnnounce(t, 2, 1), false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validator.validate(tc.request, tc.allowPending)
+			if tc.conflicting && err == nil {
+				t.Fatal("Validated conflicting request")
+			}
+			if !tc.conflicting && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Validation does not change the committed state
+	if _, ok := cb.state.owner(testFile); ok {
+		t.Fatal("Validated request changed committed state")
+	}
+	if err := newRequestValidator(cb).validate(testAddFile(t, 2), false); err != nil {
+		t.Fatal(err)
+	}
+}